@@ -5,6 +5,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
 )
 
 func RenderTemplate(w http.ResponseWriter, name string, data any) {
@@ -23,3 +26,14 @@ func RenderTemplate(w http.ResponseWriter, name string, data any) {
 		http.Error(w, "Render error", http.StatusInternalServerError)
 	}
 }
+
+// pageRenderer wraps RenderTemplate, adding the plan flags the header layout
+// needs whenever a page is rendered for a signed-in user.
+func pageRenderer(plans *entitlements.Registry) func(http.ResponseWriter, string, map[string]any) {
+	return func(w http.ResponseWriter, name string, data map[string]any) {
+		if user, ok := data["User"].(*database.User); ok && user != nil {
+			data["CanEmailSupport"] = plans.HasFeature(user.Plan, entitlements.FeatureEmailSupport)
+		}
+		RenderTemplate(w, name, data)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,16 +19,21 @@ import (
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/billing"
 	"github.com/MudassirDev/mini-hubspot/internal/database"
 	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
 	appHandler "github.com/MudassirDev/mini-hubspot/internal/handler"
 	appMiddleware "github.com/MudassirDev/mini-hubspot/internal/middleware"
+	"github.com/MudassirDev/mini-hubspot/internal/session"
 )
 
 type APIConfig struct {
-	JwtSecret   string
-	JwtExpiry   time.Duration
-	EmailSender *email.MailtrapEmailSender
+	Sessions session.Config
+	Mailer   *email.Mailer
+	Billing  *billing.Stripe
+	Plans    *entitlements.Registry
 }
 
 func main() {
@@ -58,14 +64,30 @@ func main() {
 		log.Fatal("Database unreachable:", err)
 	}
 
+	mailer, err := email.NewMailerFromEnv()
+	if err != nil {
+		log.Fatal("Email setup error:", err)
+	}
+
+	plans, err := entitlements.LoadFromEnv()
+	if err != nil {
+		log.Fatal("Plans config error:", err)
+	}
+
 	queries := database.New(db)
 	apiCfg := APIConfig{
-		JwtSecret:   jwtSecret,
-		JwtExpiry:   1 * time.Hour,
-		EmailSender: email.NewMailtrapSender(),
+		Sessions: session.Config{
+			JwtSecret:     jwtSecret,
+			AccessExpiry:  15 * time.Minute,
+			RefreshExpiry: 30 * 24 * time.Hour,
+			Secure:        appHandler.IsProduction(),
+		},
+		Mailer:  mailer,
+		Billing: billing.NewStripeFromEnv(),
+		Plans:   plans,
 	}
 
-	server := &http.Server{Addr: port, Handler: service(apiCfg, queries)}
+	server := &http.Server{Addr: port, Handler: service(apiCfg, db, queries)}
 
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 	defer serverStopCtx()
@@ -75,7 +97,8 @@ func main() {
 	go func() {
 		<-sig
 
-		shutdownCtx, _ := context.WithTimeout(serverCtx, 30*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(serverCtx, 30*time.Second)
+		defer cancel()
 
 		go func() {
 			<-shutdownCtx.Done()
@@ -101,7 +124,7 @@ func main() {
 	log.Println("Server shutdown gracefully.")
 }
 
-func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
+func service(apiCfg APIConfig, db *sql.DB, queries *database.Queries) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
@@ -109,8 +132,12 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 	cwd, _ := os.Getwd()
 	fs := http.StripPrefix("/static/", http.FileServer(http.Dir(cwd+"/frontend/static")))
 
+	contactLimit := appMiddleware.CheckLimit(apiCfg.Plans, entitlements.LimitContacts, appHandler.ContactUsage(queries))
+	freeContactLimit, _ := apiCfg.Plans.Limit(auth.PlanFree, entitlements.LimitContacts)
+	render := pageRenderer(apiCfg.Plans)
+
 	r.Group(func(r chi.Router) {
-		r.Use(appMiddleware.AuthMiddleware(queries, apiCfg.JwtSecret, false))
+		r.Use(appMiddleware.AuthMiddleware(queries, apiCfg.Sessions, false))
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 			loggedIn := false
 			user, ok := appMiddleware.GetUserFromContext(r.Context())
@@ -118,7 +145,7 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 				loggedIn = true
 			}
 
-			RenderTemplate(w, "index", map[string]any{
+			render(w, "index", map[string]any{
 				"Title":    "Home",
 				"Year":     time.Now().Year(),
 				"LoggedIn": loggedIn,
@@ -126,64 +153,271 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 			})
 		})
 		r.Get("/login", func(w http.ResponseWriter, r *http.Request) {
-			RenderTemplate(w, "login", map[string]any{
+			render(w, "login", map[string]any{
 				"Title": "Login",
 				"Year":  time.Now().Year(),
 			})
 		})
+		r.Get("/login/2fa", func(w http.ResponseWriter, r *http.Request) {
+			if _, err := session.PendingMFAUser(r, apiCfg.Sessions); err != nil {
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				return
+			}
+			render(w, "login_2fa", map[string]any{
+				"Title": "Two-Factor Authentication",
+				"Year":  time.Now().Year(),
+			})
+		})
 		r.Get("/signup", func(w http.ResponseWriter, r *http.Request) {
-			RenderTemplate(w, "signup", map[string]any{
+			render(w, "signup", map[string]any{
 				"Title": "Sign Up",
 				"Year":  time.Now().Year(),
 			})
 		})
+		r.Get("/forgot-password", func(w http.ResponseWriter, r *http.Request) {
+			render(w, "forgot_password", map[string]any{
+				"Title": "Forgot Password",
+				"Year":  time.Now().Year(),
+			})
+		})
+		r.Get("/reset-password", func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get("token")
+			_, err := queries.GetValidPasswordResetToken(r.Context(), auth.HashToken(token))
+			if err != nil && err != sql.ErrNoRows {
+				log.Printf("Failed to check reset token: %v", err)
+			}
+
+			render(w, "reset_password", map[string]any{
+				"Title":      "Reset Password",
+				"Year":       time.Now().Year(),
+				"Token":      token,
+				"ValidToken": token != "" && err == nil,
+			})
+		})
+		r.Get("/invitations/accept", func(w http.ResponseWriter, r *http.Request) {
+			user, loggedIn := appMiddleware.GetUserFromContext(r.Context())
+			invitation := appHandler.LoadInvitationPage(r.Context(), queries, apiCfg.Sessions.JwtSecret, r.URL.Query().Get("token"))
+
+			render(w, "accept_invitation", map[string]any{
+				"Title":      "Join Organization",
+				"Year":       time.Now().Year(),
+				"LoggedIn":   loggedIn,
+				"User":       user,
+				"Invitation": invitation,
+			})
+		})
 		r.Get("/plans", func(w http.ResponseWriter, r *http.Request) {
 			loggedIn := false
 			user, ok := appMiddleware.GetUserFromContext(r.Context())
+			var subscription *database.Subscription
 			if ok {
 				loggedIn = true
+				sub, err := queries.GetCurrentSubscription(r.Context(), user.ID)
+				if err == nil {
+					subscription = &sub
+				} else if !errors.Is(err, sql.ErrNoRows) {
+					log.Printf("Failed to fetch subscription: %v", err)
+				}
 			}
-			RenderTemplate(w, "plans", map[string]any{
-				"Title":    "Plans",
-				"Year":     time.Now().Year(),
-				"LoggedIn": loggedIn,
-				"User":     user,
+			render(w, "plans", map[string]any{
+				"Title":           "Plans",
+				"Year":            time.Now().Year(),
+				"LoggedIn":        loggedIn,
+				"User":            user,
+				"CheckoutSuccess": r.URL.Query().Get("checkout") == "success",
+				"Subscription":    subscription,
+				"TrialDays":       int(billing.TrialLength.Hours() / 24),
+				"ContactLimit":    freeContactLimit,
+				"Comparison":      apiCfg.Plans.Comparison(),
 			})
 		})
 	})
-	r.Get("/logout", appHandler.LogoutHandler())
-	r.Get("/verify-email", appHandler.VerifyEmailHandler(queries))
+	r.Get("/logout", appHandler.LogoutHandler(queries))
+	r.Get("/verify-email", func(w http.ResponseWriter, r *http.Request) {
+		result, err := appHandler.VerifyEmail(r.Context(), queries, r.URL.Query().Get("token"))
+		if err != nil {
+			log.Printf("Failed to verify email: %v", err)
+		}
+
+		render(w, "verify_email", map[string]any{
+			"Title":  "Verify Email",
+			"Year":   time.Now().Year(),
+			"Result": result,
+		})
+	})
+	r.Get("/unlock-account", func(w http.ResponseWriter, r *http.Request) {
+		unlocked, err := appHandler.UnlockAccount(r.Context(), queries, r.URL.Query().Get("token"))
+		if err != nil {
+			log.Printf("Failed to unlock account: %v", err)
+		}
+
+		render(w, "unlock_account", map[string]any{
+			"Title":    "Unlock Account",
+			"Year":     time.Now().Year(),
+			"Unlocked": unlocked,
+		})
+	})
 	r.Post("/webhook/stripe", appHandler.StripeWebhookHandler(queries))
+	if !appHandler.IsProduction() {
+		r.Get("/dev/emails/{name}", func(w http.ResponseWriter, r *http.Request) {
+			msg, err := apiCfg.Mailer.Preview(r.PathValue("name"))
+			if errors.Is(err, email.ErrUnknownTemplate) {
+				http.NotFound(w, r)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Render error: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if r.URL.Query().Get("format") == "text" {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				fmt.Fprintf(w, "Subject: %s\n\n%s", msg.Subject, msg.Text)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, msg.HTML)
+		})
+	}
 	r.Mount("/static/", fs)
 
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AllowContentType("application/json"))
-		r.Post("/create-account", appHandler.CreateUserHandler(queries, *apiCfg.EmailSender))
-		r.Post("/login", appHandler.LoginHandler(queries, apiCfg.JwtSecret, apiCfg.JwtExpiry))
+		r.Post("/create-account", appHandler.CreateUserHandler(db, queries, apiCfg.Mailer, apiCfg.Sessions.JwtSecret))
+		r.Post("/login", appHandler.LoginHandler(db, queries, apiCfg.Sessions, apiCfg.Mailer))
+		r.Post("/login/2fa", appHandler.LoginTwoFactorHandler(db, queries, apiCfg.Sessions, apiCfg.Mailer))
+		r.Post("/forgot-password", appHandler.ForgotPasswordHandler(db, queries, apiCfg.Mailer))
+		r.Post("/reset-password", appHandler.ResetPasswordHandler(db, queries))
 	})
 
+	// Two-factor setup stays reachable while RequireTwoFactor blocks the rest
 	r.Group(func(r chi.Router) {
-		r.Use(appMiddleware.AuthMiddleware(queries, apiCfg.JwtSecret, true))
+		r.Use(appMiddleware.AuthMiddleware(queries, apiCfg.Sessions, true))
 
-		r.Route("/contacts", func(r chi.Router) {
+		r.Route("/account/2fa", func(r chi.Router) {
 			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 				user, ok := appMiddleware.GetUserFromContext(r.Context())
 				if !ok {
 					http.Redirect(w, r, "/login", http.StatusSeeOther)
 					return
 				}
-				RenderTemplate(w, "contacts", map[string]any{
-					"Title":    "Contacts",
+
+				settings, err := queries.GetAppSettings(r.Context())
+				if err != nil {
+					log.Printf("Failed to fetch settings: %v", err)
+				}
+				codes, err := queries.GetUnusedRecoveryCodes(r.Context(), user.ID)
+				if err != nil {
+					log.Printf("Failed to fetch recovery codes: %v", err)
+				}
+
+				render(w, "two_factor", map[string]any{
+					"Title":             "Two-Factor Authentication",
+					"Year":              time.Now().Year(),
+					"LoggedIn":          true,
+					"User":              user,
+					"TwoFactorRequired": settings.RequireTwoFactor,
+					"RecoveryCodesLeft": len(codes),
+				})
+			})
+			r.Post("/setup", appHandler.SetupTwoFactorHandler(queries))
+			r.Post("/enable", appHandler.EnableTwoFactorHandler(db, queries))
+			r.Post("/recovery-codes", appHandler.RegenerateRecoveryCodesHandler(db, queries))
+			r.Post("/disable", appHandler.DisableTwoFactorHandler(db, queries))
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.AuthMiddleware(queries, apiCfg.Sessions, true))
+		r.Use(appMiddleware.RequireTwoFactor(queries))
+		r.Use(appMiddleware.LoadOrganization(queries))
+
+		r.Route("/verify-email", func(r chi.Router) {
+			r.Get("/pending", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				if !ok {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+				if user.EmailVerified {
+					http.Redirect(w, r, "/contacts", http.StatusSeeOther)
+					return
+				}
+
+				render(w, "verify_email_pending", map[string]any{
+					"Title":    "Verify Your Email",
 					"Year":     time.Now().Year(),
 					"LoggedIn": true,
 					"User":     user,
 				})
 			})
+			r.Post("/resend", appHandler.ResendVerificationHandler(db, queries, apiCfg.Mailer))
+		})
+
+		r.Post("/invitations/accept", appHandler.AcceptInvitationHandler(db, queries, apiCfg.Sessions.JwtSecret))
+
+		r.Route("/contacts", func(r chi.Router) {
+			r.Use(appMiddleware.RequireScope(auth.ScopeContactsRead, auth.ScopeContactsWrite))
+			r.Use(appMiddleware.LoadOrganization(queries))
+			r.Use(appMiddleware.RequireVerifiedEmail)
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+				fields, err := queries.GetCustomFieldsByOrganization(r.Context(), org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch custom fields: %v", err)
+				}
+				members, err := appHandler.LoadOrganizationMembers(r.Context(), queries, org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch organization members: %v", err)
+				}
+
+				render(w, "contacts", map[string]any{
+					"Title":        "Contacts",
+					"Year":         time.Now().Year(),
+					"LoggedIn":     true,
+					"User":         user,
+					"CustomFields": fields,
+					"Members":      members,
+					"CanExport":    apiCfg.Plans.HasFeature(user.Plan, entitlements.FeatureCSVExport),
+				})
+			})
 			r.Get("/all", appHandler.GetContactsHandler(queries))
-			r.Post("/new", appHandler.CreateContactHandler(queries))
+			r.With(contactLimit).Post("/new", appHandler.CreateContactHandler(db, queries, apiCfg.Plans))
+			r.Post("/import", appHandler.ImportContactsCSVHandler(db, queries, apiCfg.Plans))
+			r.Get("/trash", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				contacts, err := queries.GetTrashedContacts(r.Context(), org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch trashed contacts: %v", err)
+				}
+
+				render(w, "trash", map[string]any{
+					"Title":         "Trash",
+					"Year":          time.Now().Year(),
+					"LoggedIn":      true,
+					"User":          user,
+					"Contacts":      contacts,
+					"RetentionDays": appHandler.TrashRetentionDays(),
+				})
+			})
+			r.Get("/trash/all", appHandler.GetTrashedContactsHandler(queries))
+			r.Post("/{id}/restore", appHandler.RestoreContactHandler(db, queries, apiCfg.Plans))
+			r.Delete("/{id}/permanent", appHandler.PurgeContactHandler(queries))
 			r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
 				user, ok := appMiddleware.GetUserFromContext(r.Context())
-				if !ok {
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
 					http.Redirect(w, r, "/login", http.StatusSeeOther)
 					return
 				}
@@ -191,7 +425,7 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 				idStr := chi.URLParam(r, "id")
 				contactID, err := strconv.ParseInt(idStr, 10, 64)
 				if err != nil {
-					RenderTemplate(w, "error", map[string]any{
+					render(w, "error", map[string]any{
 						"Title":      "Invalid Contact ID",
 						"Year":       time.Now().Year(),
 						"LoggedIn":   true,
@@ -203,12 +437,12 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 				}
 
 				contact, err := queries.GetContactByID(r.Context(), database.GetContactByIDParams{
-					ID:     contactID,
-					UserID: user.ID,
+					ID:             contactID,
+					OrganizationID: org.ID(),
 				})
 				if err != nil {
 					if err == sql.ErrNoRows {
-						RenderTemplate(w, "error", map[string]any{
+						render(w, "error", map[string]any{
 							"Title":      "Contact Not Found",
 							"Year":       time.Now().Year(),
 							"LoggedIn":   true,
@@ -218,7 +452,7 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 						})
 						return
 					}
-					RenderTemplate(w, "error", map[string]any{
+					render(w, "error", map[string]any{
 						"Title":      "Server Error",
 						"Year":       time.Now().Year(),
 						"LoggedIn":   true,
@@ -229,18 +463,395 @@ func service(apiCfg APIConfig, queries *database.Queries) http.Handler {
 					return
 				}
 
-				RenderTemplate(w, "contact", map[string]any{
-					"Title":    contact.Name,
+				tags, err := queries.GetTagsByContact(r.Context(), contact.ID)
+				if err != nil {
+					log.Printf("Failed to fetch tags for contact %d: %v", contact.ID, err)
+				}
+
+				fields, err := queries.GetCustomFieldsByOrganization(r.Context(), org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch custom fields: %v", err)
+				}
+				customValues, err := appHandler.CustomValuesByKey(r.Context(), queries, fields, contact.ID)
+				if err != nil {
+					log.Printf("Failed to fetch custom values for contact %d: %v", contact.ID, err)
+				}
+
+				var linkedCompany *database.Company
+				if contact.CompanyID.Valid {
+					company, err := queries.GetCompanyByID(r.Context(), database.GetCompanyByIDParams{
+						ID:             contact.CompanyID.Int64,
+						OrganizationID: org.ID(),
+					})
+					if err == nil {
+						linkedCompany = &company
+					}
+				}
+
+				contactTasks, err := queries.GetTasksByContact(r.Context(), database.GetTasksByContactParams{
+					ContactID:      contact.ID,
+					OrganizationID: org.ID(),
+				})
+				if err != nil {
+					log.Printf("Failed to fetch tasks for contact %d: %v", contact.ID, err)
+				}
+				tasks := make([]appHandler.TaskResponse, len(contactTasks))
+				for i, t := range contactTasks {
+					tasks[i] = appHandler.NewTaskResponse(t)
+				}
+
+				activities, nextActivity, err := appHandler.LoadActivityPage(r.Context(), queries, org.ID(), contact.ID, 0, 0)
+				if err != nil {
+					log.Printf("Failed to fetch activity for contact %d: %v", contact.ID, err)
+				}
+				members, err := appHandler.LoadOrganizationMembers(r.Context(), queries, org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch organization members: %v", err)
+				}
+
+				render(w, "contact", map[string]any{
+					"Title":         contact.Name,
+					"Year":          time.Now().Year(),
+					"LoggedIn":      true,
+					"User":          user,
+					"Contact":       contact,
+					"Tags":          tags,
+					"CustomFields":  fields,
+					"CustomValues":  customValues,
+					"LinkedCompany": linkedCompany,
+					"Tasks":         tasks,
+					"Activities":    activities,
+					"NextActivity":  nextActivity,
+					"Members":       members,
+					"IsEdit":        true,
+				})
+			})
+			r.Patch("/{id}", appHandler.UpdateContactHandler(db, queries))
+			r.Delete("/{id}", appHandler.DeleteContactHandler(db, queries))
+			r.With(appMiddleware.RequireFeature(apiCfg.Plans, entitlements.FeatureCSVExport)).Get("/export", appHandler.ExportContactsCSVHandler(queries))
+			r.Get("/tags", appHandler.GetTagsHandler(queries))
+			r.Get("/duplicates", appHandler.GetDuplicateContactsHandler(queries))
+			r.Post("/merge", appHandler.MergeContactsHandler(db, queries))
+			r.Route("/fields", func(r chi.Router) {
+				r.Get("/", appHandler.GetCustomFieldsHandler(queries))
+				r.Group(func(r chi.Router) {
+					r.Use(appMiddleware.RequireOrgRole(auth.RoleAdmin))
+					r.Post("/", appHandler.CreateCustomFieldHandler(queries))
+					r.Patch("/{fieldID}", appHandler.UpdateCustomFieldHandler(queries))
+					r.Delete("/{fieldID}", appHandler.DeleteCustomFieldHandler(queries))
+				})
+			})
+			r.Get("/{id}/activity", appHandler.GetContactActivityHandler(queries))
+			r.Post("/{id}/activity", appHandler.CreateContactActivityHandler(queries))
+			r.Get("/{id}/deals", appHandler.GetContactDealsHandler(queries))
+			r.Get("/{id}/tasks", appHandler.GetContactTasksHandler(queries))
+			r.Post("/{id}/tasks", appHandler.CreateTaskHandler(db, queries))
+			r.Route("/{id}/tags", func(r chi.Router) {
+				r.Get("/", appHandler.GetContactTagsHandler(queries))
+				r.Post("/", appHandler.AddContactTagHandler(db, queries))
+				r.Put("/", appHandler.SetContactTagsHandler(db, queries))
+				r.Delete("/{tag}", appHandler.RemoveContactTagHandler(db, queries))
+			})
+		})
+
+		r.Route("/companies", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				companies, err := queries.GetCompaniesByOrganization(r.Context(), org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch companies: %v", err)
+				}
+
+				render(w, "companies", map[string]any{
+					"Title":     "Companies",
+					"Year":      time.Now().Year(),
+					"LoggedIn":  true,
+					"User":      user,
+					"Companies": companies,
+				})
+			})
+			r.Get("/all", appHandler.GetCompaniesHandler(queries))
+			r.Post("/new", appHandler.CreateCompanyHandler(queries))
+			r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				companyID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+				if err != nil {
+					render(w, "error", map[string]any{
+						"Title":      "Invalid Company ID",
+						"Year":       time.Now().Year(),
+						"LoggedIn":   true,
+						"User":       user,
+						"Message":    "The company ID provided is invalid. Please check the URL.",
+						"StatusCode": http.StatusBadRequest,
+					})
+					return
+				}
+
+				company, err := queries.GetCompanyByID(r.Context(), database.GetCompanyByIDParams{
+					ID:             companyID,
+					OrganizationID: org.ID(),
+				})
+				if err != nil {
+					render(w, "error", map[string]any{
+						"Title":      "Company Not Found",
+						"Year":       time.Now().Year(),
+						"LoggedIn":   true,
+						"User":       user,
+						"Message":    "The company you are looking for was not found or does not belong to your account.",
+						"StatusCode": http.StatusNotFound,
+					})
+					return
+				}
+
+				contacts, err := queries.GetContactsByCompany(r.Context(), database.GetContactsByCompanyParams{
+					CompanyID:      sql.NullInt64{Int64: company.ID, Valid: true},
+					OrganizationID: org.ID(),
+				})
+				if err != nil {
+					log.Printf("Failed to fetch contacts for company %d: %v", company.ID, err)
+				}
+
+				render(w, "company", map[string]any{
+					"Title":    company.Name,
 					"Year":     time.Now().Year(),
 					"LoggedIn": true,
 					"User":     user,
-					"Contact":  contact,
+					"Company":  company,
+					"Contacts": contacts,
 					"IsEdit":   true,
 				})
 			})
-			r.Patch("/{id}", appHandler.UpdateContactHandler(queries))
-			r.Delete("/{id}", appHandler.DeleteContactHandler(queries))
-			r.Get("/export", appHandler.ExportContactsCSVHandler(queries))
+			r.Patch("/{id}", appHandler.UpdateCompanyHandler(queries))
+			r.Delete("/{id}", appHandler.DeleteCompanyHandler(queries))
+			r.Get("/{id}/contacts", appHandler.GetCompanyContactsHandler(queries))
+		})
+
+		r.Route("/tasks", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				tasks, err := appHandler.LoadTaskInbox(r, queries, org.ID(), user.ID)
+				if err != nil {
+					log.Printf("Failed to fetch tasks: %v", err)
+				}
+
+				render(w, "tasks", map[string]any{
+					"Title":    "Tasks",
+					"Year":     time.Now().Year(),
+					"LoggedIn": true,
+					"User":     user,
+					"Tasks":    tasks,
+					"Due":      r.URL.Query().Get("due"),
+				})
+			})
+			r.Get("/all", appHandler.GetTaskInboxHandler(queries))
+			r.Patch("/{id}", appHandler.UpdateTaskHandler(db, queries))
+			r.Delete("/{id}", appHandler.DeleteTaskHandler(queries))
+		})
+
+		r.Route("/deals", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				if err := appHandler.EnsureDefaultPipeline(r.Context(), db, queries, org.ID(), user.ID); err != nil {
+					log.Printf("Failed to create default pipeline: %v", err)
+				}
+
+				pipelineID, _ := strconv.ParseInt(r.URL.Query().Get("pipeline_id"), 10, 64)
+				board, err := appHandler.BuildDealBoard(r.Context(), queries, org.ID(), pipelineID)
+				if err != nil {
+					log.Printf("Failed to build deal board: %v", err)
+				}
+
+				render(w, "deals", map[string]any{
+					"Title":           "Deals",
+					"Year":            time.Now().Year(),
+					"LoggedIn":        true,
+					"User":            user,
+					"Board":           board,
+					"CanAddPipelines": apiCfg.Plans.HasFeature(user.Plan, entitlements.FeaturePipelines),
+				})
+			})
+			r.Get("/all", appHandler.GetDealsHandler(queries))
+			r.Post("/new", appHandler.CreateDealHandler(db, queries))
+			r.Route("/pipelines", func(r chi.Router) {
+				r.Get("/", appHandler.GetPipelinesHandler(queries))
+				r.With(appMiddleware.RequireFeature(apiCfg.Plans, entitlements.FeaturePipelines)).Post("/", appHandler.CreatePipelineHandler(db, queries))
+				r.Patch("/{id}", appHandler.UpdatePipelineHandler(queries))
+				r.Delete("/{id}", appHandler.DeletePipelineHandler(queries))
+				r.Post("/{id}/stages", appHandler.CreateStageHandler(queries))
+			})
+			r.Patch("/stages/{stageID}", appHandler.UpdateStageHandler(queries))
+			r.Delete("/stages/{stageID}", appHandler.DeleteStageHandler(queries))
+			r.Get("/{id}", appHandler.GetDealHandler(queries))
+			r.Patch("/{id}", appHandler.UpdateDealHandler(db, queries))
+			r.Delete("/{id}", appHandler.DeleteDealHandler(db, queries))
+			r.Post("/{id}/contacts", appHandler.AddDealContactHandler(db, queries))
+			r.Delete("/{id}/contacts/{contactID}", appHandler.RemoveDealContactHandler(db, queries))
+		})
+
+		r.Route("/sessions", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				if !ok {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+				currentID, _ := appMiddleware.GetSessionIDFromContext(r.Context())
+
+				sessions, err := appHandler.LoadSessions(r.Context(), queries, user.ID, currentID)
+				if err != nil {
+					log.Printf("Failed to fetch sessions: %v", err)
+				}
+
+				render(w, "sessions", map[string]any{
+					"Title":    "Sessions",
+					"Year":     time.Now().Year(),
+					"LoggedIn": true,
+					"User":     user,
+					"Sessions": sessions,
+				})
+			})
+			r.Get("/all", appHandler.GetSessionsHandler(queries))
+			r.Delete("/{id}", appHandler.RevokeSessionHandler(queries))
+		})
+
+		r.Post("/billing/checkout", appHandler.CheckoutHandler(apiCfg.Billing))
+		r.Post("/billing/trial", appHandler.StartTrialHandler(queries))
+
+		r.Route("/organizations", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				org, orgOK := appMiddleware.GetOrganizationFromContext(r.Context())
+				if !ok || !orgOK {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				orgs, err := appHandler.LoadOrganizations(r.Context(), queries, user.ID, org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch organizations: %v", err)
+				}
+				members, err := appHandler.LoadOrganizationMembers(r.Context(), queries, org.ID())
+				if err != nil {
+					log.Printf("Failed to fetch organization members: %v", err)
+				}
+				var invitations []appHandler.InvitationResponse
+				if org.IsAdmin() {
+					invitations, err = appHandler.LoadInvitations(r.Context(), queries, org.ID())
+					if err != nil {
+						log.Printf("Failed to fetch invitations: %v", err)
+					}
+				}
+
+				render(w, "organizations", map[string]any{
+					"Title":         "Organizations",
+					"Year":          time.Now().Year(),
+					"LoggedIn":      true,
+					"User":          user,
+					"Organization":  org,
+					"Organizations": orgs,
+					"Members":       members,
+					"Invitations":   invitations,
+				})
+			})
+			r.Get("/all", appHandler.GetOrganizationsHandler(queries))
+			r.Post("/new", appHandler.CreateOrganizationHandler(db, queries))
+			r.Post("/switch", appHandler.SwitchOrganizationHandler(queries))
+			r.With(appMiddleware.RequireOrgRole(auth.RoleAdmin)).Patch("/current", appHandler.RenameOrganizationHandler(queries))
+			r.With(appMiddleware.RequireOrgRole(auth.RoleAdmin)).Patch("/members/{id}", appHandler.UpdateMemberRoleHandler(db, queries))
+			r.Delete("/members/{id}", appHandler.RemoveMemberHandler(db, queries))
+			r.Route("/invitations", func(r chi.Router) {
+				r.Use(appMiddleware.RequireOrgRole(auth.RoleAdmin))
+				r.Get("/", appHandler.GetInvitationsHandler(queries))
+				r.Post("/", appHandler.CreateInvitationHandler(db, queries, apiCfg.Mailer, apiCfg.Sessions.JwtSecret))
+				r.Delete("/{id}", appHandler.RevokeInvitationHandler(queries))
+			})
+		})
+
+		r.Route("/account/api-keys", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, ok := appMiddleware.GetUserFromContext(r.Context())
+				if !ok {
+					http.Redirect(w, r, "/login", http.StatusSeeOther)
+					return
+				}
+
+				keys, err := queries.GetAPIKeysByUser(r.Context(), user.ID)
+				if err != nil {
+					log.Printf("Failed to fetch API keys: %v", err)
+				}
+
+				render(w, "api_keys", map[string]any{
+					"Title":    "API Keys",
+					"Year":     time.Now().Year(),
+					"LoggedIn": true,
+					"User":     user,
+					"APIKeys":  appHandler.NewAPIKeyResponseList(keys),
+					"Scopes":   auth.Scopes,
+				})
+			})
+			r.Get("/all", appHandler.GetAPIKeysHandler(queries))
+			r.Post("/", appHandler.CreateAPIKeyHandler(queries))
+			r.Delete("/{id}", appHandler.RevokeAPIKeyHandler(queries))
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(appMiddleware.RequireRole(auth.RoleAdmin))
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				user, _ := appMiddleware.GetUserFromContext(r.Context())
+
+				settings, err := queries.GetAppSettings(r.Context())
+				if err != nil {
+					log.Printf("Failed to fetch settings: %v", err)
+				}
+				outbox, err := appHandler.LoadEmailOutbox(r.Context(), queries)
+				if err != nil {
+					log.Printf("Failed to fetch email outbox: %v", err)
+				}
+				stripeEvents, err := appHandler.LoadStripeEvents(r.Context(), queries)
+				if err != nil {
+					log.Printf("Failed to fetch Stripe events: %v", err)
+				}
+
+				render(w, "admin", map[string]any{
+					"Title":    "Admin",
+					"Year":     time.Now().Year(),
+					"LoggedIn": true,
+					"User":     user,
+					"Settings": settings,
+					"Outbox":   outbox,
+					"Stripe":   stripeEvents,
+				})
+			})
+			r.Get("/settings", appHandler.GetSettingsHandler(queries))
+			r.Patch("/settings", appHandler.UpdateSettingsHandler(queries))
+			r.Get("/emails", appHandler.GetEmailOutboxHandler(queries))
+			r.Post("/emails/{id}/retry", appHandler.RetryEmailHandler(queries))
+			r.Get("/stripe-events", appHandler.GetStripeEventsHandler(queries))
+			r.Post("/stripe-events/{id}/retry", appHandler.RetryStripeEventHandler(queries))
 		})
 	})
 
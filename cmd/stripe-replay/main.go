@@ -0,0 +1,94 @@
+// Command stripe-replay processes a single Stripe event again, for example
+// after fixing whatever made it fail:
+//
+//	go run ./cmd/stripe-replay evt_123
+//
+// With -fetch, an event that was never received is fetched from the Stripe
+// API and stored first.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/billing"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	fetch := flag.Bool("fetch", false, "fetch the event from Stripe if it hasn't been received")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: stripe-replay [-fetch] <event id>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	eventID := flag.Arg(0)
+
+	godotenv.Load()
+	dbConnString := os.Getenv("DATABASE_URL")
+	if dbConnString == "" {
+		log.Fatal("DATABASE_URL not set")
+	}
+	if !strings.Contains(dbConnString, "?sslmode=disable") {
+		dbConnString += "?sslmode=disable"
+	}
+
+	db, err := sql.Open("postgres", dbConnString)
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer db.Close()
+
+	queries := database.New(db)
+	mailer, err := email.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to set up email: %v", err)
+	}
+	events := &billing.EventProcessor{
+		Conn:    db,
+		DB:      queries,
+		Mailer:  mailer,
+		AppHost: os.Getenv("APP_HOST"),
+	}
+	ctx := context.Background()
+
+	err = events.Replay(ctx, eventID)
+	if errors.Is(err, sql.ErrNoRows) && *fetch {
+		if err := fetchEvent(ctx, queries, eventID); err != nil {
+			log.Fatalf("Failed to fetch event %s from Stripe: %v", eventID, err)
+		}
+		err = events.Replay(ctx, eventID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Fatalf("Event %s hasn't been received; use -fetch to get it from Stripe", eventID)
+	}
+	if err != nil {
+		log.Fatalf("Failed to process event %s: %v", eventID, err)
+	}
+
+	log.Printf("Processed event %s", eventID)
+}
+
+// fetchEvent stores an event from the Stripe API as if it had been delivered.
+func fetchEvent(ctx context.Context, db *database.Queries, id string) error {
+	client := billing.NewStripeFromEnv().Client
+	event, err := client.V1Events.Retrieve(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+	_, err = billing.RecordEvent(ctx, db, *event, event.LastResponse.RawJSON)
+	return err
+}
@@ -3,16 +3,40 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/billing"
 	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
+	appHandler "github.com/MudassirDev/mini-hubspot/internal/handler"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
+const (
+	cleanupInterval   = 24 * time.Hour
+	reminderInterval  = time.Minute
+	reminderBatchSize = 100
+	emailBatchSize    = 50
+	stripeBatchSize   = 50
+	trialBatchSize    = 100
+
+	// sentEmailRetention is how long delivered emails stay in the outbox
+	sentEmailRetention = 30 * 24 * time.Hour
+
+	// stripeEventRetention is how long processed Stripe events are kept.
+	// Stripe stops redelivering an event after three days, so this is
+	// plenty to recognise duplicates.
+	stripeEventRetention = 30 * 24 * time.Hour
+)
+
 func main() {
 	godotenv.Load()
 	dbConnString := os.Getenv("DATABASE_URL")
@@ -30,18 +54,264 @@ func main() {
 	defer db.Close()
 
 	queries := database.New(db)
+	mailer, err := email.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to set up email: %v", err)
+	}
+	plans, err := entitlements.LoadFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load plans config: %v", err)
+	}
+	// Zero when the free plan has no contacts limit
+	freeContactLimit, _ := plans.Limit(auth.PlanFree, entitlements.LimitContacts)
+	appHost := os.Getenv("APP_HOST")
+	stripeEvents := &billing.EventProcessor{
+		Conn:    db,
+		DB:      queries,
+		Mailer:  mailer,
+		AppHost: appHost,
+	}
 
+	var lastCleanup time.Time
 	for {
-		log.Println("Running scheduled task: delete expired unverified users")
-
 		ctx := context.Background()
-		err := queries.DeleteExpiredUnverifiedUsers(ctx)
-		if err != nil {
-			log.Printf("Error deleting users: %v", err)
-		} else {
-			log.Println("Expired unverified users deleted successfully")
+
+		if time.Since(lastCleanup) >= cleanupInterval {
+			log.Println("Running scheduled task: delete expired unverified users")
+
+			err := queries.DeleteExpiredUnverifiedUsers(ctx)
+			if err != nil {
+				log.Printf("Error deleting users: %v", err)
+			} else {
+				log.Println("Expired unverified users deleted successfully")
+			}
+
+			if err := queries.DeleteExpiredPasswordResetTokens(ctx); err != nil {
+				log.Printf("Error deleting password reset tokens: %v", err)
+			}
+
+			if err := queries.DeleteExpiredOrganizationInvitations(ctx); err != nil {
+				log.Printf("Error deleting expired invitations: %v", err)
+			}
+
+			if err := queries.DeleteExpiredSessions(ctx); err != nil {
+				log.Printf("Error deleting expired sessions: %v", err)
+			}
+
+			if err := queries.DeleteStaleLoginThrottles(ctx); err != nil {
+				log.Printf("Error deleting stale login throttles: %v", err)
+			}
+
+			if _, err := queries.DeleteSentEmails(ctx, time.Now().Add(-sentEmailRetention)); err != nil {
+				log.Printf("Error deleting sent emails: %v", err)
+			}
+
+			if _, err := queries.DeleteProcessedStripeEvents(ctx, time.Now().Add(-stripeEventRetention)); err != nil {
+				log.Printf("Error deleting processed Stripe events: %v", err)
+			}
+
+			retention := time.Duration(appHandler.TrashRetentionDays()) * 24 * time.Hour
+			purged, err := queries.PurgeTrashedContacts(ctx, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Error purging trashed contacts: %v", err)
+			} else {
+				log.Printf("Purged %d trashed contact(s)", purged)
+			}
+
+			lastCleanup = time.Now()
+		}
+
+		processStripeEvents(ctx, stripeEvents)
+		queueTaskReminders(ctx, db, queries, mailer, appHost)
+		queueTrialReminders(ctx, db, queries, mailer, appHost, freeContactLimit)
+		expireTrials(ctx, db, queries, mailer, appHost, freeContactLimit)
+		deliverEmails(ctx, queries, mailer.Sender)
+
+		time.Sleep(reminderInterval)
+	}
+}
+
+// processStripeEvents applies received Stripe webhook events. Failures are
+// retried on later runs with backoff.
+func processStripeEvents(ctx context.Context, events *billing.EventProcessor) {
+	processed, failed, err := events.ProcessDue(ctx, stripeBatchSize)
+	if err != nil {
+		log.Printf("Error processing Stripe events: %v", err)
+		return
+	}
+	if processed > 0 || failed > 0 {
+		log.Printf("Processed %d Stripe event(s), %d failed", processed, failed)
+	}
+}
+
+// queueTaskReminders queues an email to the assignee of every task that has
+// become due. The task is marked as reminded in the same transaction, so each
+// reminder is queued exactly once.
+func queueTaskReminders(ctx context.Context, db *sql.DB, queries *database.Queries, mailer *email.Mailer, appHost string) {
+	tasks, err := queries.GetDueTaskReminders(ctx, reminderBatchSize)
+	if err != nil {
+		log.Printf("Error fetching due tasks: %v", err)
+		return
+	}
+
+	queued := 0
+	for _, task := range tasks {
+		if err := queueTaskReminder(ctx, db, queries, mailer, appHost, task); err != nil {
+			log.Printf("Error queueing reminder for task %d: %v", task.ID, err)
+			continue
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		log.Printf("Queued %d task reminder(s)", queued)
+	}
+}
+
+func queueTaskReminder(ctx context.Context, db *sql.DB, queries *database.Queries, mailer *email.Mailer, appHost string, task database.GetDueTaskRemindersRow) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	if err := qtx.MarkTaskReminded(ctx, task.ID); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/contacts/%d", appHost, task.ContactID)
+	err = mailer.Outbox(ctx, qtx).SendTaskReminderEmail(task.Email, task.FirstName, task.Title, task.ContactName, link)
+	if err != nil {
+		return err
+	}
+
+	_, err = activity.Record(ctx, qtx, activity.Entry{
+		OrganizationID: task.OrganizationID,
+		ContactID:      task.ContactID,
+		Kind:           activity.KindTaskReminderSent,
+		Summary:        "Reminder emailed for task: " + task.Title,
+		Details:        map[string]any{"task_id": task.ID, "to": task.Email},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queueTrialReminders emails users whose Pro trial ends within
+// billing.TrialReminderLead. Each user is marked as reminded in the same
+// transaction, so the reminder is queued once.
+func queueTrialReminders(ctx context.Context, db *sql.DB, queries *database.Queries, mailer *email.Mailer, appHost string, contactLimit int) {
+	users, err := queries.GetDueTrialReminders(ctx, database.GetDueTrialRemindersParams{
+		RemindBefore: sql.NullTime{Time: time.Now().Add(billing.TrialReminderLead), Valid: true},
+		BatchSize:    trialBatchSize,
+	})
+	if err != nil {
+		log.Printf("Error fetching trials to remind: %v", err)
+		return
+	}
+
+	queued := 0
+	for _, user := range users {
+		if err := queueTrialReminder(ctx, db, queries, mailer, appHost, contactLimit, user); err != nil {
+			log.Printf("Error queueing trial reminder for user %s: %v", user.ID, err)
+			continue
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		log.Printf("Queued %d trial reminder(s)", queued)
+	}
+}
+
+func queueTrialReminder(ctx context.Context, db *sql.DB, queries *database.Queries, mailer *email.Mailer, appHost string, contactLimit int, user database.User) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	if err := qtx.MarkTrialReminded(ctx, user.ID); err != nil {
+		return err
+	}
+
+	err = mailer.Outbox(ctx, qtx).SendTrialReminderEmail(user.Email, user.FirstName, appHost+"/plans", user.TrialEndsAt.Time, contactLimit)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// expireTrials moves users whose Pro trial has ended back to the free plan.
+// Their contacts are kept even above the free limit; the contacts limit
+// blocks adding more until they are under it again.
+func expireTrials(ctx context.Context, db *sql.DB, queries *database.Queries, mailer *email.Mailer, appHost string, contactLimit int) {
+	users, err := queries.GetExpiredTrials(ctx, trialBatchSize)
+	if err != nil {
+		log.Printf("Error fetching expired trials: %v", err)
+		return
+	}
+
+	expired := 0
+	for _, user := range users {
+		if err := expireTrial(ctx, db, queries, mailer, appHost, contactLimit, user); err != nil {
+			log.Printf("Error expiring trial for user %s: %v", user.ID, err)
+			continue
 		}
+		expired++
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d trial(s)", expired)
+	}
+}
+
+func expireTrial(ctx context.Context, db *sql.DB, queries *database.Queries, mailer *email.Mailer, appHost string, contactLimit int, user database.User) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	changed, err := qtx.ExpireTrial(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		// Upgraded since it was fetched
+		return nil
+	}
+
+	// Counted the way the contacts limit counts, so the email matches what
+	// is enforced
+	contacts, err := appHandler.ContactUsage(qtx)(ctx, &user)
+	if err != nil {
+		return err
+	}
+
+	err = mailer.Outbox(ctx, qtx).SendTrialExpiredEmail(user.Email, user.FirstName, appHost+"/plans", contacts, contactLimit)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
-		time.Sleep(24 * time.Hour)
+// deliverEmails sends due emails from the outbox. Failures are retried on
+// later runs with backoff.
+func deliverEmails(ctx context.Context, queries *database.Queries, sender email.EmailSender) {
+	sent, failed, err := email.Deliver(ctx, queries, sender, emailBatchSize)
+	if err != nil {
+		log.Printf("Error delivering emails: %v", err)
+		return
+	}
+	if sent > 0 || failed > 0 {
+		log.Printf("Delivered %d email(s), %d failed", sent, failed)
 	}
 }
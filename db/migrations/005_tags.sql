@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE tags (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE contact_tags (
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (contact_id, tag_id)
+);
+
+CREATE INDEX contact_tags_tag_id_idx ON contact_tags (tag_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS contact_tags;
+DROP TABLE IF EXISTS tags;
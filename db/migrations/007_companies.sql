@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE companies (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    domain TEXT,
+    industry TEXT,
+    size TEXT,
+    address TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, domain)
+);
+
+ALTER TABLE contacts
+ADD COLUMN company_id BIGINT REFERENCES companies(id) ON DELETE SET NULL;
+
+CREATE INDEX contacts_company_id_idx ON contacts (company_id);
+
+-- +goose Down
+ALTER TABLE contacts DROP COLUMN company_id;
+DROP TABLE IF EXISTS companies;
@@ -0,0 +1,59 @@
+-- +goose Up
+CREATE TABLE pipelines (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE pipeline_stages (
+    id BIGSERIAL PRIMARY KEY,
+    pipeline_id BIGINT NOT NULL REFERENCES pipelines(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE deals (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pipeline_id BIGINT NOT NULL REFERENCES pipelines(id) ON DELETE RESTRICT,
+    stage_id BIGINT NOT NULL REFERENCES pipeline_stages(id) ON DELETE RESTRICT,
+    title TEXT NOT NULL,
+    amount_cents BIGINT NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL DEFAULT 'USD',
+    close_date DATE,
+    owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX deals_pipeline_id_idx ON deals (pipeline_id);
+
+CREATE TABLE deal_contacts (
+    deal_id BIGINT NOT NULL REFERENCES deals(id) ON DELETE CASCADE,
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (deal_id, contact_id)
+);
+
+CREATE INDEX deal_contacts_contact_id_idx ON deal_contacts (contact_id);
+
+CREATE TABLE deal_stage_history (
+    id BIGSERIAL PRIMARY KEY,
+    deal_id BIGINT NOT NULL REFERENCES deals(id) ON DELETE CASCADE,
+    from_stage_id BIGINT REFERENCES pipeline_stages(id) ON DELETE SET NULL,
+    to_stage_id BIGINT REFERENCES pipeline_stages(id) ON DELETE SET NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX deal_stage_history_deal_id_idx ON deal_stage_history (deal_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS deal_stage_history;
+DROP TABLE IF EXISTS deal_contacts;
+DROP TABLE IF EXISTS deals;
+DROP TABLE IF EXISTS pipeline_stages;
+DROP TABLE IF EXISTS pipelines;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE tasks (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    due_at TIMESTAMPTZ,
+    priority TEXT NOT NULL DEFAULT 'normal' CHECK (priority IN ('low', 'normal', 'high')),
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'in_progress', 'done')),
+    reminded_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX tasks_contact_id_idx ON tasks (contact_id);
+CREATE INDEX tasks_assignee_id_idx ON tasks (assignee_id);
+CREATE INDEX tasks_due_reminder_idx ON tasks (due_at)
+    WHERE status <> 'done' AND reminded_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS tasks;
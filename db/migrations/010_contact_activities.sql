@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE contact_activities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_id BIGINT NOT NULL REFERENCES contacts(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX contact_activities_contact_id_idx ON contact_activities (contact_id, id DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS contact_activities;
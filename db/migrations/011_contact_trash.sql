@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE contacts
+ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX contacts_deleted_at_idx ON contacts (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS contacts_deleted_at_idx;
+ALTER TABLE contacts DROP COLUMN deleted_at;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
+
+ALTER TABLE users
+ADD COLUMN password_changed_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN password_changed_at;
+DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Failed login counters keyed by "ip:<addr>" or "account:<email>"
+CREATE TABLE login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    unlock_token_hash TEXT UNIQUE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_throttles;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Emails are written here, usually in the same transaction as the change that
+-- triggers them, and delivered by the worker
+CREATE TABLE email_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    from_email TEXT NOT NULL,
+    from_name TEXT NOT NULL DEFAULT '',
+    to_email TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body_text TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX email_outbox_due_idx ON email_outbox (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS email_outbox;
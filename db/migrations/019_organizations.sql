@@ -0,0 +1,132 @@
+-- +goose Up
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX organization_members_user_id_idx ON organization_members (user_id);
+
+ALTER TABLE users
+ADD COLUMN current_organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+
+-- Every existing user gets a personal organization. It reuses the user's id,
+-- which keeps the user_id values below valid as organization ids.
+INSERT INTO organizations (id, name)
+SELECT id, first_name || '''s workspace' FROM users;
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT id, id, 'admin' FROM users;
+
+UPDATE users SET current_organization_id = id;
+
+-- Contacts belong to an organization and keep the user who owns them
+ALTER TABLE contacts RENAME COLUMN user_id TO owner_id;
+ALTER TABLE contacts ALTER COLUMN owner_id DROP NOT NULL;
+ALTER TABLE contacts DROP CONSTRAINT contacts_user_id_fkey;
+ALTER TABLE contacts
+ADD CONSTRAINT contacts_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE contacts
+ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE contacts SET organization_id = owner_id;
+ALTER TABLE contacts ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX contacts_organization_id_idx ON contacts (organization_id, id);
+
+-- Tags, custom fields, companies and the activity timeline are shared by the
+-- organization along with its contacts
+ALTER TABLE tags RENAME COLUMN user_id TO organization_id;
+ALTER TABLE tags DROP CONSTRAINT tags_user_id_fkey;
+ALTER TABLE tags
+ADD CONSTRAINT tags_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE custom_fields RENAME COLUMN user_id TO organization_id;
+ALTER TABLE custom_fields DROP CONSTRAINT custom_fields_user_id_fkey;
+ALTER TABLE custom_fields
+ADD CONSTRAINT custom_fields_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE companies RENAME COLUMN user_id TO organization_id;
+ALTER TABLE companies DROP CONSTRAINT companies_user_id_fkey;
+ALTER TABLE companies
+ADD CONSTRAINT companies_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE contact_activities RENAME COLUMN user_id TO organization_id;
+ALTER TABLE contact_activities DROP CONSTRAINT contact_activities_user_id_fkey;
+ALTER TABLE contact_activities
+ADD CONSTRAINT contact_activities_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+-- Tasks are seen by everyone in the organization; user_id stays the creator
+ALTER TABLE tasks
+ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE tasks SET organization_id = contacts.organization_id
+FROM contacts WHERE contacts.id = tasks.contact_id;
+ALTER TABLE tasks ALTER COLUMN organization_id SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN organization_id;
+
+ALTER TABLE contact_activities DROP CONSTRAINT contact_activities_organization_id_fkey;
+ALTER TABLE contact_activities RENAME COLUMN organization_id TO user_id;
+
+ALTER TABLE companies DROP CONSTRAINT companies_organization_id_fkey;
+ALTER TABLE companies RENAME COLUMN organization_id TO user_id;
+
+ALTER TABLE custom_fields DROP CONSTRAINT custom_fields_organization_id_fkey;
+ALTER TABLE custom_fields RENAME COLUMN organization_id TO user_id;
+
+ALTER TABLE tags DROP CONSTRAINT tags_organization_id_fkey;
+ALTER TABLE tags RENAME COLUMN organization_id TO user_id;
+
+-- Shared rows go back to the organization's oldest admin
+UPDATE contact_activities SET user_id = m.user_id
+FROM (SELECT DISTINCT ON (organization_id) organization_id, user_id
+      FROM organization_members WHERE role = 'admin'
+      ORDER BY organization_id, created_at) m
+WHERE contact_activities.user_id = m.organization_id;
+UPDATE companies SET user_id = m.user_id
+FROM (SELECT DISTINCT ON (organization_id) organization_id, user_id
+      FROM organization_members WHERE role = 'admin'
+      ORDER BY organization_id, created_at) m
+WHERE companies.user_id = m.organization_id;
+UPDATE custom_fields SET user_id = m.user_id
+FROM (SELECT DISTINCT ON (organization_id) organization_id, user_id
+      FROM organization_members WHERE role = 'admin'
+      ORDER BY organization_id, created_at) m
+WHERE custom_fields.user_id = m.organization_id;
+UPDATE tags SET user_id = m.user_id
+FROM (SELECT DISTINCT ON (organization_id) organization_id, user_id
+      FROM organization_members WHERE role = 'admin'
+      ORDER BY organization_id, created_at) m
+WHERE tags.user_id = m.organization_id;
+
+ALTER TABLE tags
+ADD CONSTRAINT tags_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE custom_fields
+ADD CONSTRAINT custom_fields_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE companies
+ADD CONSTRAINT companies_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE contact_activities
+ADD CONSTRAINT contact_activities_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS contacts_organization_id_idx;
+DELETE FROM contacts WHERE owner_id IS NULL;
+ALTER TABLE contacts DROP COLUMN organization_id;
+ALTER TABLE contacts DROP CONSTRAINT contacts_owner_id_fkey;
+ALTER TABLE contacts RENAME COLUMN owner_id TO user_id;
+ALTER TABLE contacts ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE contacts
+ADD CONSTRAINT contacts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE users DROP COLUMN current_organization_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Mirrors Stripe subscriptions. users.plan is derived from these rows.
+CREATE TABLE subscriptions (
+    id TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stripe_customer_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    price_id TEXT,
+    current_period_end TIMESTAMPTZ,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    trial_end TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    -- created time of the Stripe event the row was last synced from, so an
+    -- older event arriving late can't overwrite newer state
+    stripe_event_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX subscriptions_user_id_idx ON subscriptions (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS subscriptions;
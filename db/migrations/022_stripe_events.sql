@@ -0,0 +1,21 @@
+-- +goose Up
+-- Stripe webhook deliveries are stored here and processed by the worker in
+-- the order Stripe created them. The event ID keeps redeliveries from being
+-- applied twice.
+CREATE TABLE stripe_events (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    stripe_created_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX stripe_events_due_idx ON stripe_events (stripe_created_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS stripe_events;
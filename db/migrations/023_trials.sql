@@ -0,0 +1,14 @@
+-- +goose Up
+-- A user on the 'trial' plan has Pro features until trial_ends_at. The column
+-- stays set after the trial ends, so each user gets a single trial.
+ALTER TABLE users
+ADD COLUMN trial_ends_at TIMESTAMPTZ,
+ADD COLUMN trial_reminded_at TIMESTAMPTZ;
+
+CREATE INDEX users_trial_ends_at_idx ON users (trial_ends_at) WHERE plan = 'trial';
+
+-- +goose Down
+DROP INDEX IF EXISTS users_trial_ends_at_idx;
+ALTER TABLE users
+DROP COLUMN IF EXISTS trial_ends_at,
+DROP COLUMN IF EXISTS trial_reminded_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- The contacts limit counts contacts by who created them. Unlike owner_id,
+-- clients can't change it, so reassigning contacts doesn't free up room.
+ALTER TABLE contacts
+ADD COLUMN created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE contacts SET created_by = owner_id;
+
+CREATE INDEX contacts_created_by_idx ON contacts (created_by) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS contacts_created_by_idx;
+ALTER TABLE contacts DROP COLUMN IF EXISTS created_by;
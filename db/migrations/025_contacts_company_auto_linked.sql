@@ -0,0 +1,9 @@
+-- +goose Up
+-- Whether company_id was set from the email domain rather than by a user.
+-- Only automatic links follow the contact's email when it changes. Existing
+-- links can't be told apart, so they are kept as explicit.
+ALTER TABLE contacts
+ADD COLUMN company_auto_linked BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE contacts DROP COLUMN IF EXISTS company_auto_linked;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Pipelines and deals are shared by the organization, like its contacts;
+-- user_id stays the creator. Existing ones move to their creator's oldest
+-- organization, which is the personal one they signed up with.
+ALTER TABLE pipelines
+ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE pipelines SET organization_id = m.organization_id
+FROM (SELECT DISTINCT ON (user_id) user_id, organization_id
+      FROM organization_members
+      ORDER BY user_id, created_at) m
+WHERE pipelines.user_id = m.user_id;
+
+-- Nobody can reach the pipelines of users without an organization
+DELETE FROM deals
+WHERE pipeline_id IN (SELECT id FROM pipelines WHERE organization_id IS NULL);
+DELETE FROM pipelines WHERE organization_id IS NULL;
+
+ALTER TABLE pipelines ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX pipelines_organization_id_idx ON pipelines (organization_id);
+
+ALTER TABLE deals
+ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE deals SET organization_id = pipelines.organization_id
+FROM pipelines WHERE pipelines.id = deals.pipeline_id;
+ALTER TABLE deals ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX deals_organization_id_idx ON deals (organization_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS deals_organization_id_idx;
+ALTER TABLE deals DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS pipelines_organization_id_idx;
+ALTER TABLE pipelines DROP COLUMN IF EXISTS organization_id;
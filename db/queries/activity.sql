@@ -0,0 +1,19 @@
+-- name: CreateContactActivity :one
+INSERT INTO contact_activities (
+    organization_id, contact_id, actor_id, kind, summary, details
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetContactActivities :many
+SELECT * FROM contact_activities
+WHERE contact_id = sqlc.arg('contact_id')
+  AND organization_id = sqlc.arg('organization_id')
+  AND (sqlc.arg('before')::bigint = 0 OR id < sqlc.arg('before'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: MoveContactActivities :exec
+UPDATE contact_activities
+SET contact_id = sqlc.arg('winner_id')
+WHERE contact_id = sqlc.arg('loser_id');
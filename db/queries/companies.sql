@@ -0,0 +1,43 @@
+-- name: CreateCompany :one
+INSERT INTO companies (
+    organization_id, name, domain, industry, size, address
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetCompaniesByOrganization :many
+SELECT * FROM companies
+WHERE organization_id = $1
+ORDER BY name;
+
+-- name: GetCompanyByID :one
+SELECT * FROM companies
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetCompanyByDomain :one
+SELECT * FROM companies
+WHERE organization_id = $1 AND domain = $2;
+
+-- name: UpdateCompany :one
+UPDATE companies
+SET name = $3,
+    domain = $4,
+    industry = $5,
+    size = $6,
+    address = $7,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+RETURNING *;
+
+-- name: DeleteCompany :exec
+DELETE FROM companies
+WHERE id = $1 AND organization_id = $2;
+
+-- name: AssociateContactsByDomain :execrows
+UPDATE contacts
+SET company_id = sqlc.arg('company_id'),
+    company_auto_linked = true,
+    updated_at = NOW()
+WHERE organization_id = sqlc.arg('organization_id')
+  AND company_id IS NULL
+  AND lower(split_part(email, '@', 2)) = sqlc.arg('domain')::text;
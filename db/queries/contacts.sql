@@ -1,21 +1,27 @@
 -- name: CreateContact :one
 INSERT INTO contacts (
-    user_id, name, email, phone, company, position, notes
+    organization_id, owner_id, created_by, name, email, phone, company, position, notes
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
--- name: CountContactsByUser :one
+-- name: LockContactLimit :exec
+SELECT pg_advisory_xact_lock(hashtextextended('contacts_limit:' || sqlc.arg('user_id')::uuid::text, 0));
+
+-- name: CountContactsCreatedBy :one
 SELECT COUNT(*) FROM contacts
-WHERE user_id = $1;
+WHERE created_by = $1
+  AND deleted_at IS NULL;
 
--- name: GetContactsByUser :many
+-- name: GetContactsByOrganization :many
 SELECT * FROM contacts
-WHERE user_id = $1;
+WHERE organization_id = $1
+  AND deleted_at IS NULL;
 
 -- name: GetContactByID :one
 SELECT * FROM contacts
-WHERE id = $1 AND user_id = $2;
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NULL;
 
 -- name: UpdateContact :one
 UPDATE contacts
@@ -25,18 +31,20 @@ SET name = $3,
     company = $6,
     position = $7,
     notes = $8,
+    owner_id = $9,
     updated_at = NOW()
-WHERE id = $1 AND user_id = $2
+WHERE id = $1 AND organization_id = $2
 RETURNING *;
 
 -- name: DeleteContact :exec
 DELETE FROM contacts
-WHERE id = $1 AND user_id = $2;
+WHERE id = $1 AND organization_id = $2;
 
 -- name: GetContactsPaginated :many
 SELECT *
 FROM contacts
-WHERE user_id = sqlc.arg('user_id')
+WHERE organization_id = sqlc.arg('organization_id')
+  AND deleted_at IS NULL
   AND id > sqlc.arg('after')
   AND (
     sqlc.arg('search')::text IS NULL OR
@@ -56,5 +64,80 @@ WHERE user_id = sqlc.arg('user_id')
   AND (
     NOT sqlc.arg('require_non_empty_email')::bool OR (email IS NOT NULL AND email <> '')
   )
+  AND (
+    coalesce(cardinality(sqlc.arg('tags')::text[]), 0) = 0 OR (
+      SELECT COUNT(DISTINCT tags.name)
+      FROM contact_tags
+      JOIN tags ON tags.id = contact_tags.tag_id
+      WHERE contact_tags.contact_id = contacts.id
+        AND tags.name = ANY(sqlc.arg('tags')::text[])
+    ) >= CASE WHEN sqlc.arg('match_all_tags')::bool THEN cardinality(sqlc.arg('tags')::text[]) ELSE 1 END
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(sqlc.arg('custom_field_keys')::text[], sqlc.arg('custom_field_values')::text[]) AS filter(key, value)
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM contact_custom_values
+      JOIN custom_fields ON custom_fields.id = contact_custom_values.field_id
+      WHERE contact_custom_values.contact_id = contacts.id
+        AND custom_fields.key = filter.key
+        AND contact_custom_values.value = filter.value
+    )
+  )
 ORDER BY id
 LIMIT sqlc.arg('limit');
+
+-- name: SetContactCompany :exec
+UPDATE contacts
+SET company_id = $3,
+    company_auto_linked = $4,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetContactsByCompany :many
+SELECT * FROM contacts
+WHERE company_id = $1 AND organization_id = $2
+  AND deleted_at IS NULL
+ORDER BY name;
+
+-- name: ReleaseContactsOwnedBy :exec
+UPDATE contacts
+SET owner_id = NULL,
+    updated_at = NOW()
+WHERE organization_id = $1 AND owner_id = $2;
+
+-- name: SoftDeleteContact :execrows
+UPDATE contacts
+SET deleted_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NULL;
+
+-- name: GetTrashedContacts :many
+SELECT * FROM contacts
+WHERE organization_id = $1
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: GetTrashedContactByID :one
+SELECT * FROM contacts
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NOT NULL;
+
+-- name: RestoreContact :one
+UPDATE contacts
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeContact :execrows
+DELETE FROM contacts
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NOT NULL;
+
+-- name: PurgeTrashedContacts :execrows
+DELETE FROM contacts
+WHERE deleted_at IS NOT NULL
+  AND deleted_at < sqlc.arg('deleted_before')::timestamptz;
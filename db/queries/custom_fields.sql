@@ -0,0 +1,52 @@
+-- name: CreateCustomField :one
+INSERT INTO custom_fields (
+    organization_id, name, key, field_type, options, required, position
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetCustomFieldsByOrganization :many
+SELECT * FROM custom_fields
+WHERE organization_id = $1
+ORDER BY position, id;
+
+-- name: GetCustomFieldByID :one
+SELECT * FROM custom_fields
+WHERE id = $1 AND organization_id = $2;
+
+-- name: UpdateCustomField :one
+UPDATE custom_fields
+SET name = $3,
+    options = $4,
+    required = $5,
+    position = $6,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+RETURNING *;
+
+-- name: DeleteCustomField :exec
+DELETE FROM custom_fields
+WHERE id = $1 AND organization_id = $2;
+
+-- name: UpsertContactCustomValue :exec
+INSERT INTO contact_custom_values (contact_id, field_id, value)
+VALUES ($1, $2, $3)
+ON CONFLICT (contact_id, field_id) DO UPDATE
+SET value = EXCLUDED.value,
+    updated_at = NOW();
+
+-- name: DeleteContactCustomValue :exec
+DELETE FROM contact_custom_values
+WHERE contact_id = $1 AND field_id = $2;
+
+-- name: GetCustomValuesByContactIDs :many
+SELECT contact_id, field_id, value
+FROM contact_custom_values
+WHERE contact_id = ANY(sqlc.arg('contact_ids')::bigint[]);
+
+-- name: MoveContactCustomValues :exec
+INSERT INTO contact_custom_values (contact_id, field_id, value)
+SELECT sqlc.arg('winner_id')::bigint, field_id, value
+FROM contact_custom_values
+WHERE contact_id = sqlc.arg('loser_id')
+ON CONFLICT (contact_id, field_id) DO NOTHING;
@@ -0,0 +1,146 @@
+-- name: CreatePipeline :one
+INSERT INTO pipelines (organization_id, user_id, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPipelinesByOrganization :many
+SELECT * FROM pipelines
+WHERE organization_id = $1
+ORDER BY created_at, id;
+
+-- name: GetPipelineByID :one
+SELECT * FROM pipelines
+WHERE id = $1 AND organization_id = $2;
+
+-- name: CountPipelinesByOrganization :one
+SELECT COUNT(*) FROM pipelines
+WHERE organization_id = $1;
+
+-- name: UpdatePipeline :one
+UPDATE pipelines
+SET name = $3,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+RETURNING *;
+
+-- name: DeletePipeline :exec
+DELETE FROM pipelines
+WHERE id = $1 AND organization_id = $2;
+
+-- name: CreatePipelineStage :one
+INSERT INTO pipeline_stages (pipeline_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetStagesByPipeline :many
+SELECT * FROM pipeline_stages
+WHERE pipeline_id = $1
+ORDER BY position, id;
+
+-- name: GetStageByID :one
+SELECT pipeline_stages.*
+FROM pipeline_stages
+JOIN pipelines ON pipelines.id = pipeline_stages.pipeline_id
+WHERE pipeline_stages.id = $1 AND pipelines.organization_id = $2;
+
+-- name: UpdatePipelineStage :one
+UPDATE pipeline_stages
+SET name = $2,
+    position = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePipelineStage :exec
+DELETE FROM pipeline_stages
+WHERE id = $1;
+
+-- name: CountDealsByStage :one
+SELECT COUNT(*) FROM deals
+WHERE stage_id = $1;
+
+-- name: CreateDeal :one
+INSERT INTO deals (
+    organization_id, user_id, pipeline_id, stage_id, title, amount_cents, currency, close_date, owner_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetDealByID :one
+SELECT * FROM deals
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetDealsByPipeline :many
+SELECT * FROM deals
+WHERE pipeline_id = $1 AND organization_id = $2
+ORDER BY updated_at DESC, id;
+
+-- name: GetDealsByContact :many
+SELECT deals.*
+FROM deals
+JOIN deal_contacts ON deal_contacts.deal_id = deals.id
+WHERE deal_contacts.contact_id = $1 AND deals.organization_id = $2
+ORDER BY deals.updated_at DESC;
+
+-- name: UpdateDeal :one
+UPDATE deals
+SET stage_id = $3,
+    title = $4,
+    amount_cents = $5,
+    currency = $6,
+    close_date = $7,
+    owner_id = $8,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+RETURNING *;
+
+-- name: DeleteDeal :exec
+DELETE FROM deals
+WHERE id = $1 AND organization_id = $2;
+
+-- name: ReleaseDealsOwnedBy :exec
+UPDATE deals
+SET owner_id = NULL,
+    updated_at = NOW()
+WHERE organization_id = $1 AND owner_id = $2;
+
+-- name: AddContactToDeal :execrows
+INSERT INTO deal_contacts (deal_id, contact_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveContactFromDeal :exec
+DELETE FROM deal_contacts
+WHERE deal_id = $1 AND contact_id = $2;
+
+-- name: GetContactsByDeal :many
+SELECT contacts.*
+FROM contacts
+JOIN deal_contacts ON deal_contacts.contact_id = contacts.id
+WHERE deal_contacts.deal_id = $1
+  AND contacts.deleted_at IS NULL
+ORDER BY contacts.name;
+
+-- name: MoveDealContacts :exec
+INSERT INTO deal_contacts (deal_id, contact_id, created_at)
+SELECT deal_id, sqlc.arg('winner_id')::bigint, created_at
+FROM deal_contacts
+WHERE contact_id = sqlc.arg('loser_id')
+ON CONFLICT DO NOTHING;
+
+-- name: CreateDealStageChange :exec
+INSERT INTO deal_stage_history (deal_id, from_stage_id, to_stage_id, changed_by)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetDealStageHistory :many
+SELECT deal_stage_history.id,
+       deal_stage_history.from_stage_id,
+       from_stage.name AS from_stage_name,
+       deal_stage_history.to_stage_id,
+       to_stage.name AS to_stage_name,
+       deal_stage_history.changed_by,
+       deal_stage_history.changed_at
+FROM deal_stage_history
+LEFT JOIN pipeline_stages AS from_stage ON from_stage.id = deal_stage_history.from_stage_id
+LEFT JOIN pipeline_stages AS to_stage ON to_stage.id = deal_stage_history.to_stage_id
+WHERE deal_stage_history.deal_id = $1
+ORDER BY deal_stage_history.changed_at, deal_stage_history.id;
@@ -0,0 +1,62 @@
+-- name: EnqueueEmail :exec
+INSERT INTO email_outbox (from_email, from_name, to_email, subject, body_text, body_html, category)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ClaimDueEmails :many
+UPDATE email_outbox
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id IN (
+    SELECT id FROM email_outbox
+    WHERE status = 'pending'
+      AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkEmailSent :exec
+UPDATE email_outbox
+SET status = 'sent',
+    attempts = attempts + 1,
+    last_error = NULL,
+    sent_at = NOW()
+WHERE id = $1;
+
+-- name: RetryEmailLater :exec
+UPDATE email_outbox
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE id = $1;
+
+-- name: DeadLetterEmail :exec
+UPDATE email_outbox
+SET status = 'dead',
+    attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1;
+
+-- name: RequeueDeadEmail :execrows
+UPDATE email_outbox
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW()
+WHERE id = $1 AND status = 'dead';
+
+-- name: CountEmailsByStatus :many
+SELECT status, COUNT(*) AS count
+FROM email_outbox
+GROUP BY status;
+
+-- name: GetFailingEmails :many
+SELECT * FROM email_outbox
+WHERE status = 'dead'
+   OR (status = 'pending' AND attempts > 0)
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: DeleteSentEmails :execrows
+DELETE FROM email_outbox
+WHERE status = 'sent'
+  AND sent_at < sqlc.arg('sent_before')::timestamptz;
@@ -0,0 +1,49 @@
+-- name: CreateOrganizationInvitation :one
+INSERT INTO organization_invitations (organization_id, email, role, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetOrganizationInvitation :one
+SELECT organization_invitations.*, organizations.name AS organization_name,
+       COALESCE(users.first_name, '')::TEXT AS inviter_name
+FROM organization_invitations
+JOIN organizations ON organizations.id = organization_invitations.organization_id
+LEFT JOIN users ON users.id = organization_invitations.invited_by
+WHERE organization_invitations.id = $1;
+
+-- name: GetPendingOrganizationInvitations :many
+SELECT organization_invitations.*, COALESCE(users.first_name, '')::TEXT AS inviter_name
+FROM organization_invitations
+LEFT JOIN users ON users.id = organization_invitations.invited_by
+WHERE organization_invitations.organization_id = $1
+  AND organization_invitations.accepted_at IS NULL
+  AND organization_invitations.revoked_at IS NULL
+  AND organization_invitations.expires_at > NOW()
+ORDER BY organization_invitations.created_at DESC;
+
+-- name: RevokeOrganizationInvitation :execrows
+UPDATE organization_invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL;
+
+-- name: RevokePendingInvitationsForEmail :exec
+UPDATE organization_invitations
+SET revoked_at = NOW()
+WHERE organization_id = $1 AND email = $2
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL;
+
+-- name: AcceptOrganizationInvitation :execrows
+UPDATE organization_invitations
+SET accepted_at = NOW(),
+    accepted_by = $2
+WHERE id = $1
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW();
+
+-- name: DeleteExpiredOrganizationInvitations :exec
+DELETE FROM organization_invitations
+WHERE expires_at < NOW() OR revoked_at IS NOT NULL OR accepted_at IS NOT NULL;
@@ -0,0 +1,37 @@
+-- name: GetLoginThrottles :many
+SELECT * FROM login_throttles
+WHERE key = ANY(sqlc.arg('keys')::text[]);
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (sqlc.arg('key'), 1, NOW())
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_throttles.last_failure_at < sqlc.arg('reset_before') THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = NOW()
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = $2
+WHERE key = $1;
+
+-- name: SetLoginUnlockToken :exec
+UPDATE login_throttles
+SET unlock_token_hash = $2
+WHERE key = $1;
+
+-- name: UnlockLogin :execrows
+DELETE FROM login_throttles
+WHERE unlock_token_hash = $1 AND locked_until > NOW();
+
+-- name: ClearLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+-- name: DeleteStaleLoginThrottles :exec
+DELETE FROM login_throttles
+WHERE last_failure_at < NOW() - INTERVAL '1 day'
+  AND (locked_until IS NULL OR locked_until < NOW());
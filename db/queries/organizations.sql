@@ -0,0 +1,59 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (name)
+VALUES ($1)
+RETURNING *;
+
+-- name: RenameOrganization :one
+UPDATE organizations
+SET name = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: LockOrganization :one
+SELECT name FROM organizations
+WHERE id = $1
+FOR UPDATE;
+
+-- name: AddOrganizationMember :exec
+INSERT INTO organization_members (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: GetOrganizationMembership :one
+SELECT organizations.*, organization_members.role
+FROM organizations
+JOIN organization_members ON organization_members.organization_id = organizations.id
+WHERE organizations.id = $1 AND organization_members.user_id = $2;
+
+-- name: GetOrganizationsByUser :many
+SELECT organizations.*, organization_members.role
+FROM organizations
+JOIN organization_members ON organization_members.organization_id = organizations.id
+WHERE organization_members.user_id = $1
+ORDER BY organization_members.created_at, organizations.id;
+
+-- name: GetOrganizationMembers :many
+SELECT users.id, users.username, users.email, users.first_name, users.last_name,
+       organization_members.role, organization_members.created_at AS joined_at
+FROM organization_members
+JOIN users ON users.id = organization_members.user_id
+WHERE organization_members.organization_id = $1
+ORDER BY organization_members.created_at, users.id;
+
+-- name: GetOrganizationMember :one
+SELECT * FROM organization_members
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: CountOrganizationAdmins :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = $1 AND role = 'admin';
+
+-- name: UpdateOrganizationMemberRole :execrows
+UPDATE organization_members
+SET role = $3
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE organization_id = $1 AND user_id = $2;
@@ -0,0 +1,46 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetSessionByID :one
+SELECT * FROM sessions
+WHERE id = $1;
+
+-- name: GetSessionByRefreshToken :one
+SELECT * FROM sessions
+WHERE refresh_token_hash = sqlc.arg('token_hash')
+   OR previous_token_hash = sqlc.arg('token_hash')
+LIMIT 1;
+
+-- name: GetActiveSessionsByUser :many
+SELECT * FROM sessions
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+ORDER BY last_used_at DESC;
+
+-- name: RotateSessionToken :execrows
+UPDATE sessions
+SET previous_token_hash = refresh_token_hash,
+    refresh_token_hash = sqlc.arg('new_token_hash'),
+    rotated_at = NOW(),
+    last_used_at = NOW(),
+    expires_at = sqlc.arg('expires_at')
+WHERE id = sqlc.arg('id')
+  AND refresh_token_hash = sqlc.arg('old_token_hash')
+  AND revoked_at IS NULL;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions
+WHERE expires_at < NOW() OR revoked_at IS NOT NULL;
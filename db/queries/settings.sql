@@ -0,0 +1,9 @@
+-- name: GetAppSettings :one
+SELECT * FROM app_settings
+LIMIT 1;
+
+-- name: UpdateRequireTwoFactor :one
+UPDATE app_settings
+SET require_two_factor = $1,
+    updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,67 @@
+-- name: InsertStripeEvent :execrows
+INSERT INTO stripe_events (id, type, payload, stripe_created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetStripeEvent :one
+SELECT * FROM stripe_events
+WHERE id = $1;
+
+-- name: ClaimDueStripeEvents :many
+UPDATE stripe_events
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id IN (
+    SELECT id FROM stripe_events
+    WHERE status = 'pending'
+      AND next_attempt_at <= NOW()
+    ORDER BY stripe_created_at, id
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkStripeEventProcessed :exec
+UPDATE stripe_events
+SET status = 'processed',
+    attempts = attempts + 1,
+    last_error = NULL,
+    processed_at = NOW()
+WHERE id = $1;
+
+-- name: RetryStripeEventLater :exec
+UPDATE stripe_events
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE id = $1;
+
+-- name: FailStripeEvent :exec
+UPDATE stripe_events
+SET status = 'failed',
+    attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1;
+
+-- name: RequeueFailedStripeEvent :execrows
+UPDATE stripe_events
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW()
+WHERE id = $1 AND status = 'failed';
+
+-- name: CountStripeEventsByStatus :many
+SELECT status, COUNT(*) AS count
+FROM stripe_events
+GROUP BY status;
+
+-- name: GetFailingStripeEvents :many
+SELECT * FROM stripe_events
+WHERE status = 'failed'
+   OR (status = 'pending' AND attempts > 0)
+ORDER BY stripe_created_at DESC
+LIMIT $1;
+
+-- name: DeleteProcessedStripeEvents :execrows
+DELETE FROM stripe_events
+WHERE status = 'processed'
+  AND processed_at < sqlc.arg('processed_before')::timestamptz;
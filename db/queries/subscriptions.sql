@@ -0,0 +1,46 @@
+-- name: UpsertSubscription :execrows
+INSERT INTO subscriptions (
+    id, user_id, stripe_customer_id, status, price_id, current_period_end,
+    cancel_at_period_end, trial_end, canceled_at, stripe_event_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (id) DO UPDATE
+SET status = EXCLUDED.status,
+    price_id = EXCLUDED.price_id,
+    current_period_end = EXCLUDED.current_period_end,
+    cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+    trial_end = EXCLUDED.trial_end,
+    canceled_at = EXCLUDED.canceled_at,
+    stripe_event_at = EXCLUDED.stripe_event_at,
+    updated_at = NOW()
+WHERE subscriptions.stripe_event_at <= EXCLUDED.stripe_event_at;
+
+-- name: GetSubscription :one
+SELECT * FROM subscriptions
+WHERE id = $1;
+
+-- name: GetCurrentSubscription :one
+SELECT * FROM subscriptions
+WHERE user_id = $1
+ORDER BY status IN ('active', 'trialing', 'past_due') DESC, created_at DESC
+LIMIT 1;
+
+-- name: SyncUserPlan :one
+UPDATE users
+SET plan = CASE
+        -- Pro users from before subscriptions were tracked have no rows yet;
+        -- they keep Pro until an event for their subscription is replayed
+        WHEN plan = 'pro' AND NOT EXISTS (
+            SELECT 1 FROM subscriptions
+            WHERE subscriptions.user_id = users.id
+        ) THEN 'pro'
+        WHEN EXISTS (
+            SELECT 1 FROM subscriptions
+            WHERE subscriptions.user_id = users.id
+              AND subscriptions.status IN ('active', 'trialing', 'past_due')
+        ) THEN 'pro'
+        WHEN trial_ends_at > NOW() THEN 'trial'
+        ELSE 'free'
+    END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING plan;
@@ -0,0 +1,48 @@
+-- name: UpsertTag :one
+INSERT INTO tags (organization_id, name)
+VALUES ($1, $2)
+ON CONFLICT (organization_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: GetTagsByOrganization :many
+SELECT * FROM tags
+WHERE organization_id = $1
+ORDER BY name;
+
+-- name: AddTagToContact :execrows
+INSERT INTO contact_tags (contact_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveTagFromContact :execrows
+DELETE FROM contact_tags
+USING tags
+WHERE contact_tags.tag_id = tags.id
+  AND contact_tags.contact_id = $1
+  AND tags.organization_id = $2
+  AND tags.name = $3;
+
+-- name: ClearContactTags :exec
+DELETE FROM contact_tags
+WHERE contact_id = $1;
+
+-- name: GetTagsByContact :many
+SELECT tags.*
+FROM tags
+JOIN contact_tags ON contact_tags.tag_id = tags.id
+WHERE contact_tags.contact_id = $1
+ORDER BY tags.name;
+
+-- name: GetTagNamesByContactIDs :many
+SELECT contact_tags.contact_id, tags.name
+FROM contact_tags
+JOIN tags ON tags.id = contact_tags.tag_id
+WHERE contact_tags.contact_id = ANY(sqlc.arg('contact_ids')::bigint[])
+ORDER BY tags.name;
+
+-- name: MoveContactTags :exec
+INSERT INTO contact_tags (contact_id, tag_id, created_at)
+SELECT sqlc.arg('winner_id')::bigint, tag_id, created_at
+FROM contact_tags
+WHERE contact_id = sqlc.arg('loser_id')
+ON CONFLICT DO NOTHING;
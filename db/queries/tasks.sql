@@ -0,0 +1,71 @@
+-- name: CreateTask :one
+INSERT INTO tasks (
+    organization_id, user_id, contact_id, assignee_id, title, description, due_at, priority, status
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetTaskByID :one
+SELECT * FROM tasks
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetTasksByContact :many
+SELECT * FROM tasks
+WHERE contact_id = $1 AND organization_id = $2
+ORDER BY status = 'done', due_at NULLS LAST, id;
+
+-- name: GetTaskInbox :many
+SELECT tasks.*, contacts.name AS contact_name
+FROM tasks
+JOIN contacts ON contacts.id = tasks.contact_id
+WHERE tasks.organization_id = sqlc.arg('organization_id')
+  AND (tasks.user_id = sqlc.arg('user_id') OR tasks.assignee_id = sqlc.arg('user_id'))
+  AND contacts.deleted_at IS NULL
+  AND (sqlc.arg('status')::text = '' OR tasks.status = sqlc.arg('status'))
+  AND (sqlc.arg('include_done')::bool OR tasks.status <> 'done')
+  AND (sqlc.narg('due_before')::timestamptz IS NULL OR tasks.due_at <= sqlc.narg('due_before'))
+ORDER BY tasks.due_at NULLS LAST, tasks.id;
+
+-- name: UpdateTask :one
+UPDATE tasks
+SET assignee_id = $3,
+    title = $4,
+    description = $5,
+    due_at = $6,
+    priority = $7,
+    status = $8,
+    reminded_at = CASE WHEN due_at IS DISTINCT FROM $6 THEN NULL ELSE reminded_at END,
+    completed_at = CASE
+        WHEN $8 = 'done' THEN COALESCE(completed_at, NOW())
+        ELSE NULL
+    END,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+RETURNING *;
+
+-- name: DeleteTask :exec
+DELETE FROM tasks
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetDueTaskReminders :many
+SELECT tasks.id, tasks.organization_id, tasks.title, tasks.due_at, tasks.contact_id,
+       contacts.name AS contact_name, users.email, users.first_name
+FROM tasks
+JOIN contacts ON contacts.id = tasks.contact_id
+JOIN users ON users.id = COALESCE(tasks.assignee_id, tasks.user_id)
+WHERE tasks.status <> 'done'
+  AND tasks.reminded_at IS NULL
+  AND contacts.deleted_at IS NULL
+  AND tasks.due_at <= NOW()
+ORDER BY tasks.due_at
+LIMIT $1;
+
+-- name: MarkTaskReminded :exec
+UPDATE tasks
+SET reminded_at = NOW()
+WHERE id = $1;
+
+-- name: MoveContactTasks :exec
+UPDATE tasks
+SET contact_id = sqlc.arg('winner_id')
+WHERE contact_id = sqlc.arg('loser_id');
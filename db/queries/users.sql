@@ -38,11 +38,98 @@ SET email_verified = true,
     updated_at = NOW()
 WHERE id = $1;
 
--- name: UpdateStripeCustomerIDByEmail :exec
-UPDATE users SET stripe_customer_id = $2 WHERE email = $1;
+-- name: ResendVerificationToken :execrows
+UPDATE users
+SET verification_token = sqlc.arg('verification_token'),
+    token_sent_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+  AND email_verified = false
+  AND (token_sent_at IS NULL OR token_sent_at < sqlc.arg('sent_before'));
+
+-- name: UpdateStripeCustomerID :exec
+UPDATE users SET stripe_customer_id = $2 WHERE id = $1;
+
+-- name: GetUserByStripeCustomerID :one
+SELECT * FROM users
+WHERE stripe_customer_id = $1
+LIMIT 1;
 
--- name: UpgradeUserPlanByEmail :exec
-UPDATE users SET plan = 'pro' WHERE email = $1;
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2,
+    password_changed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
 
--- name: DowngradeUserPlanByStripeCustomerID :exec
-UPDATE users SET plan = 'free' WHERE stripe_customer_id = $1;
+-- name: SetTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2,
+    totp_enabled = false,
+    totp_last_step = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled = true,
+    updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL;
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled = false,
+    totp_last_step = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1
+  AND (totp_last_step IS NULL OR totp_last_step < $2);
+
+-- name: SetCurrentOrganization :exec
+UPDATE users
+SET current_organization_id = $2
+WHERE id = $1;
+
+-- name: StartTrial :execrows
+UPDATE users
+SET plan = 'trial',
+    trial_ends_at = $2,
+    trial_reminded_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND plan = 'free'
+  AND trial_ends_at IS NULL;
+
+-- name: GetDueTrialReminders :many
+SELECT * FROM users
+WHERE plan = 'trial'
+  AND trial_reminded_at IS NULL
+  AND trial_ends_at > NOW()
+  AND trial_ends_at <= sqlc.arg('remind_before')
+ORDER BY trial_ends_at
+LIMIT sqlc.arg('batch_size');
+
+-- name: MarkTrialReminded :exec
+UPDATE users
+SET trial_reminded_at = NOW()
+WHERE id = $1;
+
+-- name: GetExpiredTrials :many
+SELECT * FROM users
+WHERE plan = 'trial'
+  AND trial_ends_at <= NOW()
+ORDER BY trial_ends_at
+LIMIT $1;
+
+-- name: ExpireTrial :execrows
+UPDATE users
+SET plan = 'free',
+    updated_at = NOW()
+WHERE id = $1
+  AND plan = 'trial'
+  AND trial_ends_at <= NOW();
@@ -0,0 +1,65 @@
+import { postJSON } from "./api.js";
+
+function renderActivity(entry) {
+    const li = document.createElement("li");
+
+    const when = document.createElement("small");
+    when.textContent = new Date(entry.created_at).toLocaleString();
+    li.append(when, document.createElement("br"));
+
+    const summary = document.createElement("strong");
+    summary.textContent = entry.summary;
+    li.append(summary);
+
+    if (entry.details?.body) {
+        const body = document.createElement("p");
+        body.textContent = entry.details.body;
+        li.append(body);
+    }
+    return li;
+}
+
+export function setupActivity() {
+    const section = document.querySelector("#contact-activity");
+    if (!section) return;
+
+    const id = section.dataset.id;
+    const form = document.querySelector("#activity-form");
+    const list = document.querySelector("#activity-list");
+    const loadMore = document.querySelector("#load-activity");
+
+    form?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        try {
+            await postJSON(`/contacts/${id}/activity`, {
+                kind: form.kind.value,
+                subject: form.subject.value,
+                body: form.body.value,
+            });
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to log activity: " + err.message);
+        }
+    });
+
+    loadMore?.addEventListener("click", async (e) => {
+        e.preventDefault();
+
+        try {
+            const res = await fetch(`/contacts/${id}/activity?before=${loadMore.dataset.before}`);
+            if (!res.ok) throw new Error(await res.text());
+            const data = await res.json();
+
+            data.activities.forEach((entry) => list.append(renderActivity(entry)));
+
+            if (data.next_cursor) {
+                loadMore.dataset.before = data.next_cursor;
+            } else {
+                loadMore.remove();
+            }
+        } catch (err) {
+            alert("Failed to load activity: " + err.message);
+        }
+    });
+}
@@ -0,0 +1,45 @@
+export function setupAdmin() {
+    const toggle = document.querySelector("#require-two-factor");
+
+    toggle?.addEventListener("change", async () => {
+        try {
+            const res = await fetch("/admin/settings", {
+                method: "PATCH",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ require_two_factor: toggle.checked }),
+            });
+            if (!res.ok) throw new Error(await res.text());
+        } catch (err) {
+            toggle.checked = !toggle.checked;
+            alert("Failed to update settings: " + err.message);
+        }
+    });
+
+    document.querySelectorAll(".retry-email").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            try {
+                const res = await fetch(`/admin/emails/${btn.dataset.id}/retry`, {
+                    method: "POST",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to retry email: " + err.message);
+            }
+        });
+    });
+
+    document.querySelectorAll(".retry-stripe-event").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            try {
+                const res = await fetch(`/admin/stripe-events/${btn.dataset.id}/retry`, {
+                    method: "POST",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to retry event: " + err.message);
+            }
+        });
+    });
+}
@@ -0,0 +1,41 @@
+import { postJSON } from "./api.js";
+
+export function setupAPIKeys() {
+    const form = document.querySelector("#api-key-form");
+
+    form?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        const scopes = [...form.querySelectorAll('input[name="scopes"]:checked')].map((el) => el.value);
+        if (scopes.length === 0) {
+            alert("Pick at least one scope");
+            return;
+        }
+
+        try {
+            const res = await postJSON("/account/api-keys", { name: form.name.value, scopes });
+            form.reset();
+            document.querySelector("#new-api-key-value").textContent = res.key;
+            document.querySelector("#new-api-key").hidden = false;
+        } catch (err) {
+            alert("Failed to create API key: " + err.message);
+        }
+    });
+
+    document.querySelectorAll(".revoke-api-key").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            const confirmed = confirm("Revoke this key? Scripts using it will stop working.");
+            if (!confirmed) return;
+
+            try {
+                const res = await fetch(`/account/api-keys/${btn.dataset.id}`, {
+                    method: "DELETE",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to revoke API key: " + err.message);
+            }
+        });
+    });
+}
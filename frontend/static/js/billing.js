@@ -0,0 +1,29 @@
+import { postJSON } from "./api.js";
+
+export function setupPlans() {
+    const upgradeBtn = document.querySelector("#upgrade-pro");
+
+    upgradeBtn?.addEventListener("click", async () => {
+        upgradeBtn.setAttribute("aria-busy", "true");
+        try {
+            const res = await postJSON("/billing/checkout", {});
+            window.location.href = res.url;
+        } catch (err) {
+            upgradeBtn.removeAttribute("aria-busy");
+            alert("Could not start checkout: " + err.message);
+        }
+    });
+
+    const trialBtn = document.querySelector("#start-trial");
+
+    trialBtn?.addEventListener("click", async () => {
+        trialBtn.setAttribute("aria-busy", "true");
+        try {
+            await postJSON("/billing/trial", {});
+            window.location.reload();
+        } catch (err) {
+            trialBtn.removeAttribute("aria-busy");
+            alert("Could not start trial: " + err.message);
+        }
+    });
+}
@@ -0,0 +1,73 @@
+import { postJSON } from "./api.js";
+
+export function CompanyFormSetup() {
+    const modal = document.querySelector("#company-modal");
+    const openBtn = document.querySelector("#add-company");
+    const closeBtn = document.querySelector("#cancel-company-modal");
+    const form = document.querySelector("#company-form");
+
+    openBtn?.addEventListener("click", (e) => {
+        e.preventDefault();
+        modal.showModal();
+    });
+
+    closeBtn?.addEventListener("click", () => modal.close());
+
+    form?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        const data = {
+            name: form.name.value,
+            domain: form.domain.value,
+            industry: form.industry.value,
+            size: form.size.value,
+            address: form.address.value,
+        };
+
+        const isEdit = form.id && form.id.value;
+
+        try {
+            if (isEdit) {
+                const res = await fetch(`/companies/${form.id.value}`, {
+                    method: "PATCH",
+                    headers: { "Content-Type": "application/json" },
+                    body: JSON.stringify(data),
+                });
+                if (!res.ok) throw new Error(await res.text());
+            } else {
+                await postJSON("/companies/new", data);
+            }
+            window.location.reload();
+        } catch (err) {
+            alert("Error saving company: " + err.message);
+        }
+    });
+}
+
+export function setupCompanies() {
+    CompanyFormSetup();
+}
+
+export function setupCompany() {
+    CompanyFormSetup();
+
+    const deleteBtn = document.querySelector("#delete-company");
+
+    deleteBtn?.addEventListener("click", async (e) => {
+        e.preventDefault();
+
+        const confirmed = confirm("Delete this company? Linked contacts are kept but unlinked.");
+        if (!confirmed) return;
+
+        try {
+            const res = await fetch(`/companies/${deleteBtn.dataset.id}`, {
+                method: "DELETE",
+            });
+            if (!res.ok) throw new Error(await res.text());
+
+            window.location.href = "/companies";
+        } catch (err) {
+            alert("Failed to delete company: " + err.message);
+        }
+    });
+}
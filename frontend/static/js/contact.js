@@ -1,4 +1,7 @@
+import { postJSON } from "./api.js";
 import { ContactFormSetup } from "./util.js";
+import { setupContactTasks } from "./tasks.js";
+import { setupActivity } from "./activity.js";
 
 export function setupContact() {
     ContactFormSetup();
@@ -10,7 +13,7 @@ export function setupContact() {
 
         const id = deleteBtn.dataset.id;
 
-        const confirmed = confirm("Are you sure you want to delete this contact?");
+        const confirmed = confirm("Move this contact to the trash? You can restore it from the trash page.");
         if (!confirmed) return;
 
         try {
@@ -28,4 +31,41 @@ export function setupContact() {
             alert("Failed to delete contact: " + err.message);
         }
     });
+
+    setupTags();
+    setupContactTasks();
+    setupActivity();
+}
+
+function setupTags() {
+    const section = document.querySelector("#contact-tags");
+    const form = document.querySelector("#tag-form");
+    if (!section || !form) return;
+
+    const id = section.dataset.id;
+
+    form.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        try {
+            await postJSON(`/contacts/${id}/tags`, { name: form.tag.value });
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to add tag: " + err.message);
+        }
+    });
+
+    section.querySelectorAll(".remove-tag").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            try {
+                const res = await fetch(`/contacts/${id}/tags/${encodeURIComponent(btn.dataset.tag)}`, {
+                    method: "DELETE",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to remove tag: " + err.message);
+            }
+        });
+    });
 }
\ No newline at end of file
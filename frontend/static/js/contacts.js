@@ -5,10 +5,14 @@ export function setupContacts() {
     let afterCursor = null;
     let searchTerm = '';
     let filterField = '';
+    let tagFilter = '';
+    let tagMatch = 'any';
 
     const tableBody = document.querySelector("#contacts-table tbody");
     const searchInput = document.querySelector("#contact-search");
     const filterSelect = document.querySelector("#filter-field");
+    const tagInput = document.querySelector("#tag-filter");
+    const tagMatchSelect = document.querySelector("#tag-match");
 
     const prevBtn = document.querySelector("#prev-btn");
     const nextBtn = document.querySelector("#next-btn");
@@ -34,6 +38,22 @@ export function setupContacts() {
         fetchContacts();
     });
 
+    tagInput?.addEventListener("input", debounce(() => {
+        tagFilter = tagInput.value.trim();
+        page = 1;
+        cursors.length = 1;
+        cursors[0] = null;
+        fetchContacts();
+    }, 400));
+
+    tagMatchSelect?.addEventListener("change", () => {
+        tagMatch = tagMatchSelect.value;
+        page = 1;
+        cursors.length = 1;
+        cursors[0] = null;
+        fetchContacts();
+    });
+
     prevBtn.addEventListener("click", () => {
         if (page <= 1) return;
         page--;
@@ -62,6 +82,8 @@ export function setupContacts() {
             url += `&${fieldMap[filterField]}=true`;
         }
 
+        if (tagFilter) url += `&tags=${encodeURIComponent(tagFilter)}&tag_match=${tagMatch}`;
+
         if (after) url += `&after=${after}`;
 
         try {
@@ -76,6 +98,7 @@ export function setupContacts() {
           <td>${contact.name}</td>
           <td>${contact.email || ""}</td>
           <td>${contact.company || ""}</td>
+          <td>${(contact.tags || []).join(", ")}</td>
           <td><a href="/contacts/${contact['contact_id']}" class="secondary">Details</a></td>
         `;
                 tableBody.appendChild(tr);
@@ -87,7 +110,7 @@ export function setupContacts() {
             prevBtn.disabled = page <= 1;
             nextBtn.disabled = !next_cursor;
         } catch (err) {
-            tableBody.innerHTML = `<tr><td colspan="4">Failed to load contacts.</td></tr>`;
+            tableBody.innerHTML = `<tr><td colspan="5">Failed to load contacts.</td></tr>`;
         }
     }
 
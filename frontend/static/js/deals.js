@@ -0,0 +1,94 @@
+import { postJSON } from "./api.js";
+
+export function setupDeals() {
+    const modal = document.querySelector("#deal-modal");
+    const form = document.querySelector("#deal-form");
+
+    document.querySelector("#pipeline-select")?.addEventListener("change", (e) => {
+        e.target.form.submit();
+    });
+
+    document.querySelector("#add-deal")?.addEventListener("click", (e) => {
+        e.preventDefault();
+        modal.showModal();
+    });
+
+    document.querySelector("#cancel-deal-modal")?.addEventListener("click", () => modal.close());
+
+    form?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        const data = {
+            title: form.title.value,
+            stage_id: Number(form.stage_id.value),
+            amount: Number(form.amount.value || 0),
+            currency: form.currency.value,
+            close_date: form.close_date.value,
+        };
+
+        try {
+            await postJSON("/deals/new", data);
+            window.location.reload();
+        } catch (err) {
+            alert("Error saving deal: " + err.message);
+        }
+    });
+
+    document.querySelectorAll(".deal-stage").forEach((select) => {
+        select.addEventListener("change", async () => {
+            try {
+                const res = await fetch(`/deals/${select.dataset.id}`, {
+                    method: "PATCH",
+                    headers: { "Content-Type": "application/json" },
+                    body: JSON.stringify({ stage_id: Number(select.value) }),
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to move deal: " + err.message);
+            }
+        });
+    });
+
+    document.querySelectorAll(".delete-deal").forEach((btn) => {
+        btn.addEventListener("click", async (e) => {
+            e.preventDefault();
+            if (!confirm("Delete this deal?")) return;
+
+            try {
+                const res = await fetch(`/deals/${btn.dataset.id}`, { method: "DELETE" });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to delete deal: " + err.message);
+            }
+        });
+    });
+
+    const addStage = document.querySelector("#add-stage");
+    addStage?.addEventListener("click", async (e) => {
+        e.preventDefault();
+        const name = prompt("Stage name");
+        if (!name) return;
+
+        try {
+            await postJSON(`/deals/pipelines/${addStage.dataset.pipeline}/stages`, { name });
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to add stage: " + err.message);
+        }
+    });
+
+    document.querySelector("#add-pipeline")?.addEventListener("click", async (e) => {
+        e.preventDefault();
+        const name = prompt("Pipeline name");
+        if (!name) return;
+
+        try {
+            const pipeline = await postJSON("/deals/pipelines", { name });
+            window.location.href = `/deals?pipeline_id=${pipeline.id}`;
+        } catch (err) {
+            alert("Failed to create pipeline: " + err.message);
+        }
+    });
+}
@@ -0,0 +1,49 @@
+import { postJSON } from "./api.js";
+
+export function setupAcceptInvitation() {
+    const btn = document.querySelector("#accept-invitation");
+
+    btn?.addEventListener("click", async () => {
+        try {
+            await postJSON("/invitations/accept", { token: btn.dataset.token });
+            window.location.href = "/contacts";
+        } catch (err) {
+            alert("Failed to accept invitation: " + err.message);
+        }
+    });
+}
+
+export function setupInvitations() {
+    const form = document.querySelector("#invitation-form");
+
+    form?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        try {
+            await postJSON("/organizations/invitations", {
+                email: form.email.value,
+                role: form.role.value,
+            });
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to send invitation: " + err.message);
+        }
+    });
+
+    document.querySelectorAll(".revoke-invitation").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            const confirmed = confirm("Revoke this invitation? The emailed link will stop working.");
+            if (!confirmed) return;
+
+            try {
+                const res = await fetch(`/organizations/invitations/${btn.dataset.id}`, {
+                    method: "DELETE",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to revoke invitation: " + err.message);
+            }
+        });
+    });
+}
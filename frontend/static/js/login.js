@@ -31,6 +31,9 @@ export function setupLogin() {
         [form.email, form.password].forEach(clearError);
     };
 
+    // Set when the user came from an invitation link
+    const inviteToken = new URLSearchParams(window.location.search).get('invite');
+
     form.addEventListener('submit', async (e) => {
         e.preventDefault();
         clearAllErrors();
@@ -39,17 +42,25 @@ export function setupLogin() {
             email: form.email.value,
             password: form.password.value,
         };
+        if (inviteToken) payload.invite_token = inviteToken;
 
         try {
-            await postJSON('/login', payload);
-            window.location.href = '/';
+            const res = await postJSON('/login', payload);
+            if (res.mfa_required) {
+                window.location.href = inviteToken
+                    ? `/login/2fa?invite=${encodeURIComponent(inviteToken)}`
+                    : '/login/2fa';
+                return;
+            }
+            if (res.invite_error) alert(res.invite_error);
+            window.location.href = res.organization ? '/contacts' : '/';
         } catch (err) {
-            const msg = err.message.toLowerCase();
+            const msg = err.message;
 
-            if (msg.includes('User not found')) {
-                showError(form.email, 'Email not found or invalid', 'email-error');
-            } else if (msg.includes('Invalid password')) {
-                showError(form.password, 'Incorrect password', 'password-error');
+            if (msg.includes('Invalid email or password')) {
+                showError(form.password, 'Invalid email or password', 'password-error');
+            } else if (msg.includes('Too many login attempts')) {
+                showError(form.password, 'Too many login attempts. Please try again later.', 'password-error');
             } else {
                 alert(`Login failed: ${err.message}`);
             }
@@ -1,13 +1,42 @@
 import { setupLogin } from './login.js';
 import { setupSignup } from './signup.js';
+import { setupForgotPassword, setupResetPassword } from './password.js';
 import { setupContacts } from './contacts.js';
 import { setupContact } from './contact.js';
+import { setupCompanies, setupCompany } from './companies.js';
+import { setupDeals } from './deals.js';
+import { setupTasks } from './tasks.js';
+import { setupTrash } from './trash.js';
+import { setupSessions } from './sessions.js';
+import { setupOrganizations } from './organizations.js';
+import { setupAcceptInvitation } from './invitations.js';
+import { setupPlans } from './billing.js';
+import { setupLoginTwoFactor, setupTwoFactor } from './twofactor.js';
+import { setupAdmin } from './admin.js';
+import { setupAPIKeys } from './apikeys.js';
+import { setupVerifyEmailPending } from './verify.js';
 
 document.addEventListener('DOMContentLoaded', () => {
     const page = document.body.querySelector("#content")?.dataset.page;
 
     if (page === 'login') setupLogin();
     if (page === 'signup') setupSignup();
+    if (page === 'forgot-password') setupForgotPassword();
+    if (page === 'reset-password') setupResetPassword();
     if (page === 'contacts') setupContacts();
     if (page === 'contact') setupContact();
+    if (page === 'companies') setupCompanies();
+    if (page === 'company') setupCompany();
+    if (page === 'deals') setupDeals();
+    if (page === 'tasks') setupTasks();
+    if (page === 'trash') setupTrash();
+    if (page === 'sessions') setupSessions();
+    if (page === 'organizations') setupOrganizations();
+    if (page === 'accept-invitation') setupAcceptInvitation();
+    if (page === 'plans') setupPlans();
+    if (page === 'login-2fa') setupLoginTwoFactor();
+    if (page === 'two-factor') setupTwoFactor();
+    if (page === 'admin') setupAdmin();
+    if (page === 'api-keys') setupAPIKeys();
+    if (page === 'verify-email-pending') setupVerifyEmailPending();
 });
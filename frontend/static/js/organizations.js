@@ -0,0 +1,82 @@
+import { postJSON } from "./api.js";
+import { setupInvitations } from "./invitations.js";
+
+export function setupOrganizations() {
+    const form = document.querySelector("#organization-form");
+    const renameForm = document.querySelector("#rename-organization-form");
+
+    setupInvitations();
+
+    form?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        try {
+            await postJSON("/organizations/new", { name: form.name.value });
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to create organization: " + err.message);
+        }
+    });
+
+    renameForm?.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        try {
+            const res = await fetch("/organizations/current", {
+                method: "PATCH",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ name: renameForm.name.value }),
+            });
+            if (!res.ok) throw new Error(await res.text());
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to rename organization: " + err.message);
+        }
+    });
+
+    document.querySelectorAll(".switch-organization").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            try {
+                await postJSON("/organizations/switch", { organization_id: btn.dataset.id });
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to switch organization: " + err.message);
+            }
+        });
+    });
+
+    document.querySelectorAll(".member-role").forEach((select) => {
+        select.addEventListener("change", async () => {
+            try {
+                const res = await fetch(`/organizations/members/${select.dataset.id}`, {
+                    method: "PATCH",
+                    headers: { "Content-Type": "application/json" },
+                    body: JSON.stringify({ role: select.value }),
+                });
+                if (!res.ok) throw new Error(await res.text());
+            } catch (err) {
+                alert("Failed to change role: " + err.message);
+            }
+            window.location.reload();
+        });
+    });
+
+    document.querySelectorAll(".remove-member").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            const confirmed = confirm(btn.dataset.self
+                ? "Leave this organization? You will lose access to its contacts."
+                : "Remove this member? Their contacts will stay with the organization.");
+            if (!confirmed) return;
+
+            try {
+                const res = await fetch(`/organizations/members/${btn.dataset.id}`, {
+                    method: "DELETE",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to remove member: " + err.message);
+            }
+        });
+    });
+}
@@ -0,0 +1,45 @@
+import { postJSON } from './api.js';
+
+export function setupForgotPassword() {
+    const form = document.querySelector('#forgot-password-form');
+    const message = document.querySelector('#forgot-password-message');
+    if (!form) return;
+
+    form.addEventListener('submit', async (e) => {
+        e.preventDefault();
+
+        try {
+            const res = await postJSON('/forgot-password', { email: form.email.value });
+            form.hidden = true;
+            message.textContent = res.message;
+            message.hidden = false;
+        } catch (err) {
+            alert(`Could not send reset link: ${err.message}`);
+        }
+    });
+}
+
+export function setupResetPassword() {
+    const form = document.querySelector('#reset-password-form');
+    if (!form) return;
+
+    form.addEventListener('submit', async (e) => {
+        e.preventDefault();
+
+        if (form.password.value !== form.confirm_password.value) {
+            alert('Passwords do not match');
+            return;
+        }
+
+        try {
+            await postJSON('/reset-password', {
+                token: form.token.value,
+                password: form.password.value,
+            });
+            alert('Password updated. Please log in with your new password.');
+            window.location.href = '/login';
+        } catch (err) {
+            alert(`Could not reset password: ${err.message}`);
+        }
+    });
+}
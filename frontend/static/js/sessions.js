@@ -0,0 +1,18 @@
+export function setupSessions() {
+    document.querySelectorAll(".revoke-session").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            const confirmed = confirm("Sign this device out?");
+            if (!confirmed) return;
+
+            try {
+                const res = await fetch(`/sessions/${btn.dataset.id}`, {
+                    method: "DELETE",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to revoke session: " + err.message);
+            }
+        });
+    });
+}
@@ -31,6 +31,11 @@ export function setupSignup() {
         [form.username, form.email, form.first_name, form.last_name, form.password].forEach(clearError);
     };
 
+    // Invitation links send people here with the invited email filled in
+    const params = new URLSearchParams(window.location.search);
+    const inviteToken = params.get('invite');
+    if (params.get('email')) form.email.value = params.get('email');
+
     form.addEventListener('submit', async (e) => {
         e.preventDefault();
         clearAllErrors();
@@ -42,6 +47,7 @@ export function setupSignup() {
             last_name: form.last_name.value,
             password: form.password.value,
         };
+        if (inviteToken) payload.invite_token = inviteToken;
 
         try {
             await postJSON('/create-account', payload);
@@ -53,6 +59,8 @@ export function setupSignup() {
                 showError(form.username, 'Username is already taken', 'username-error');
             } else if (msg.includes('Email already in use')) {
                 showError(form.email, 'Email is already taken', 'email-error');
+            } else if (msg.includes('different email address')) {
+                showError(form.email, 'Use the email address the invitation was sent to', 'email-error');
             } else {
                 alert(`Signup failed: ${msg}`);
             }
@@ -0,0 +1,66 @@
+import { postJSON } from "./api.js";
+
+// setupTaskActions wires the done checkboxes and delete buttons inside root.
+function setupTaskActions(root) {
+    root.querySelectorAll(".complete-task").forEach((box) => {
+        box.addEventListener("change", async () => {
+            try {
+                const res = await fetch(`/tasks/${box.dataset.id}`, {
+                    method: "PATCH",
+                    headers: { "Content-Type": "application/json" },
+                    body: JSON.stringify({ status: box.checked ? "done" : "open" }),
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                box.checked = !box.checked;
+                alert("Failed to update task: " + err.message);
+            }
+        });
+    });
+
+    root.querySelectorAll(".delete-task").forEach((btn) => {
+        btn.addEventListener("click", async (e) => {
+            e.preventDefault();
+            if (!confirm("Delete this task?")) return;
+
+            try {
+                const res = await fetch(`/tasks/${btn.dataset.id}`, { method: "DELETE" });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to delete task: " + err.message);
+            }
+        });
+    });
+}
+
+export function setupTasks() {
+    setupTaskActions(document);
+}
+
+export function setupContactTasks() {
+    const section = document.querySelector("#contact-tasks");
+    const form = document.querySelector("#task-form");
+    if (!section || !form) return;
+
+    setupTaskActions(section);
+
+    form.addEventListener("submit", async (e) => {
+        e.preventDefault();
+
+        // datetime-local has no zone, so send it as the browser's local time
+        const due = form.due_at.value ? new Date(form.due_at.value).toISOString() : "";
+
+        try {
+            await postJSON(`/contacts/${section.dataset.id}/tasks`, {
+                title: form.title.value,
+                due_at: due,
+                priority: form.priority.value,
+            });
+            window.location.reload();
+        } catch (err) {
+            alert("Failed to add task: " + err.message);
+        }
+    });
+}
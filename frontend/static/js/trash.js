@@ -0,0 +1,32 @@
+export function setupTrash() {
+    document.querySelectorAll(".restore-contact").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            try {
+                const res = await fetch(`/contacts/${btn.dataset.id}/restore`, {
+                    method: "POST",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to restore contact: " + err.message);
+            }
+        });
+    });
+
+    document.querySelectorAll(".purge-contact").forEach((btn) => {
+        btn.addEventListener("click", async () => {
+            const confirmed = confirm("Delete this contact forever? This cannot be undone.");
+            if (!confirmed) return;
+
+            try {
+                const res = await fetch(`/contacts/${btn.dataset.id}/permanent`, {
+                    method: "DELETE",
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+            } catch (err) {
+                alert("Failed to delete contact: " + err.message);
+            }
+        });
+    });
+}
@@ -0,0 +1,94 @@
+import { postJSON } from './api.js';
+
+export function setupLoginTwoFactor() {
+    const form = document.querySelector('#login-2fa-form');
+    if (!form) return;
+
+    form.addEventListener('submit', async (e) => {
+        e.preventDefault();
+
+        const payload = { code: form.code.value };
+        const inviteToken = new URLSearchParams(window.location.search).get('invite');
+        if (inviteToken) payload.invite_token = inviteToken;
+
+        try {
+            const res = await postJSON('/login/2fa', payload);
+            if (res.invite_error) alert(res.invite_error);
+            window.location.href = res.organization ? '/contacts' : '/';
+        } catch (err) {
+            alert(`Verification failed: ${err.message}`);
+        }
+    });
+}
+
+export function setupTwoFactor() {
+    const startBtn = document.querySelector('#start-two-factor');
+    const setup = document.querySelector('#two-factor-setup');
+    const enableForm = document.querySelector('#two-factor-enable-form');
+    const manageForm = document.querySelector('#two-factor-manage-form');
+
+    const showRecoveryCodes = (codes) => {
+        const list = document.querySelector('#recovery-code-list');
+        list.innerHTML = '';
+        codes.forEach((code) => {
+            const li = document.createElement('li');
+            const el = document.createElement('code');
+            el.textContent = code;
+            li.appendChild(el);
+            list.appendChild(li);
+        });
+        document.querySelector('#recovery-codes').hidden = false;
+    };
+
+    startBtn?.addEventListener('click', async () => {
+        try {
+            const res = await postJSON('/account/2fa/setup', {});
+            const link = document.querySelector('#two-factor-uri');
+            link.href = res.provisioning_uri;
+            link.textContent = res.provisioning_uri;
+            document.querySelector('#two-factor-secret').textContent = res.secret;
+            startBtn.hidden = true;
+            setup.hidden = false;
+        } catch (err) {
+            alert(`Could not start setup: ${err.message}`);
+        }
+    });
+
+    enableForm?.addEventListener('submit', async (e) => {
+        e.preventDefault();
+
+        try {
+            const res = await postJSON('/account/2fa/enable', { code: enableForm.code.value });
+            setup.hidden = true;
+            showRecoveryCodes(res.recovery_codes);
+        } catch (err) {
+            alert(`Could not enable 2FA: ${err.message}`);
+        }
+    });
+
+    manageForm?.addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const action = e.submitter?.value;
+
+        try {
+            if (action === 'disable') {
+                if (!confirm('Turn off two-factor authentication?')) return;
+
+                const res = await fetch('/account/2fa/disable', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ code: manageForm.code.value }),
+                });
+                if (!res.ok) throw new Error(await res.text());
+                window.location.reload();
+                return;
+            }
+
+            const res = await postJSON('/account/2fa/recovery-codes', { code: manageForm.code.value });
+            manageForm.reset();
+            showRecoveryCodes(res.recovery_codes);
+        } catch (err) {
+            alert(`Request failed: ${err.message}`);
+        }
+    });
+}
@@ -23,7 +23,9 @@ export function ContactFormSetup(onSuccess) {
             company: form.company.value,
             position: form.position.value,
             notes: form.notes.value,
+            custom_fields: collectCustomFields(form),
         };
+        if (form.owner_id) data.owner_id = form.owner_id.value;
 
         const isEdit = form.id && form.id.value;
         const endpoint = isEdit
@@ -54,3 +56,23 @@ export function ContactFormSetup(onSuccess) {
         }
     });
 }
+
+function collectCustomFields(form) {
+    const values = {};
+
+    form.querySelectorAll("[data-custom-field]").forEach((input) => {
+        const key = input.dataset.customField;
+
+        if (input.dataset.type === "boolean") {
+            values[key] = input.checked;
+        } else if (input.value === "") {
+            values[key] = null;
+        } else if (input.dataset.type === "number") {
+            values[key] = Number(input.value);
+        } else {
+            values[key] = input.value;
+        }
+    });
+
+    return values;
+}
@@ -0,0 +1,21 @@
+import { postJSON } from './api.js';
+
+export function setupVerifyEmailPending() {
+    const button = document.querySelector('#resend-verification');
+    const message = document.querySelector('#resend-verification-message');
+    if (!button) return;
+
+    button.addEventListener('click', async () => {
+        button.disabled = true;
+
+        try {
+            const res = await postJSON('/verify-email/resend', {});
+            message.textContent = res.message;
+            message.hidden = false;
+        } catch (err) {
+            alert(`Could not resend verification email: ${err.message}`);
+        } finally {
+            button.disabled = false;
+        }
+    });
+}
@@ -0,0 +1,138 @@
+package activity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+// Kinds of entries on a contact's timeline
+const (
+	KindContactCreated   = "contact_created"
+	KindFieldChanged     = "field_changed"
+	KindContactMerged    = "contact_merged"
+	KindContactTrashed   = "contact_trashed"
+	KindContactRestored  = "contact_restored"
+	KindNote             = "note"
+	KindEmail            = "email"
+	KindTaskCreated      = "task_created"
+	KindTaskCompleted    = "task_completed"
+	KindTaskReminderSent = "task_reminder_sent"
+	KindDealLinked       = "deal_linked"
+	KindDealUnlinked     = "deal_unlinked"
+	KindDealStageChanged = "deal_stage_changed"
+	KindTagAdded         = "tag_added"
+	KindTagRemoved       = "tag_removed"
+)
+
+// Entry is one event on a contact's timeline. Details is stored as JSON.
+type Entry struct {
+	OrganizationID uuid.UUID
+	ContactID      int64
+	ActorID        uuid.NullUUID
+	Kind           string
+	Summary        string
+	Details        map[string]any
+}
+
+// FieldChange is one field edited by an update.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Record appends an entry to the contact's activity log.
+func Record(ctx context.Context, db *database.Queries, e Entry) (database.ContactActivity, error) {
+	if e.Details == nil {
+		e.Details = map[string]any{}
+	}
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return database.ContactActivity{}, err
+	}
+
+	return db.CreateContactActivity(ctx, database.CreateContactActivityParams{
+		OrganizationID: e.OrganizationID,
+		ContactID:      e.ContactID,
+		ActorID:        e.ActorID,
+		Kind:           e.Kind,
+		Summary:        e.Summary,
+		Details:        details,
+	})
+}
+
+// RecordFieldChanges writes one field_changed entry per change.
+func RecordFieldChanges(ctx context.Context, db *database.Queries, contact database.Contact, actor uuid.UUID, changes []FieldChange) error {
+	for _, c := range changes {
+		_, err := Record(ctx, db, Entry{
+			OrganizationID: contact.OrganizationID,
+			ContactID:      contact.ID,
+			ActorID:        uuid.NullUUID{UUID: actor, Valid: true},
+			Kind:           KindFieldChanged,
+			Summary:        c.Summary(),
+			Details: map[string]any{
+				"field": c.Field,
+				"from":  c.From,
+				"to":    c.To,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary describes the change in one line. Long free text such as notes is
+// left to the details.
+func (c FieldChange) Summary() string {
+	switch {
+	case c.Field == "notes":
+		return "Updated notes"
+	case c.From == "":
+		return fmt.Sprintf("Set %s to %q", c.Field, c.To)
+	case c.To == "":
+		return fmt.Sprintf("Cleared %s (was %q)", c.Field, c.From)
+	}
+	return fmt.Sprintf("Changed %s from %q to %q", c.Field, c.From, c.To)
+}
+
+// DiffContacts lists the built-in fields that differ between two versions of
+// a contact.
+func DiffContacts(before, after database.Contact) []FieldChange {
+	var changes []FieldChange
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	add("name", before.Name, after.Name)
+	add("email", before.Email.String, after.Email.String)
+	add("phone", before.Phone.String, after.Phone.String)
+	add("company", before.Company.String, after.Company.String)
+	add("position", before.Position.String, after.Position.String)
+	add("notes", before.Notes.String, after.Notes.String)
+	add("company_id", nullInt(before.CompanyID), nullInt(after.CompanyID))
+	add("owner_id", nullUUID(before.OwnerID), nullUUID(after.OwnerID))
+	return changes
+}
+
+func nullInt(n sql.NullInt64) string {
+	if !n.Valid {
+		return ""
+	}
+	return fmt.Sprint(n.Int64)
+}
+
+func nullUUID(n uuid.NullUUID) string {
+	if !n.Valid {
+		return ""
+	}
+	return n.UUID.String()
+}
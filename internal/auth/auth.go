@@ -2,9 +2,13 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -13,8 +17,36 @@ import (
 
 const (
 	ISSUER = "tool"
+
+	// mfaAudience marks tokens that only prove the password step of a
+	// two-factor login
+	mfaAudience = "mfa"
+
+	// invitationAudience marks tokens in organization invitation links
+	invitationAudience = "invitation"
+)
+
+// Passwords have to be long enough to resist guessing. bcrypt only looks at
+// the first 72 bytes, so longer ones are refused rather than cut short.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
 )
 
+var ErrExpiredToken = errors.New("token has expired")
+
+// CheckPassword enforces the password rules wherever a password is set. Its
+// errors are meant for the user.
+func CheckPassword(pass string) error {
+	if utf8.RuneCountInString(pass) < MinPasswordLength {
+		return fmt.Errorf("Password must be at least %d characters", MinPasswordLength)
+	}
+	if len(pass) > MaxPasswordLength {
+		return fmt.Errorf("Password must be at most %d bytes", MaxPasswordLength)
+	}
+	return nil
+}
+
 func HashPassword(pass string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
 	if err != nil {
@@ -27,44 +59,107 @@ func VerifyPassword(passwordString, hashString string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashString), []byte(passwordString))
 }
 
-func MakeJWT(userID uuid.UUID, expiresIn time.Duration, secretKey string) (string, error) {
+// Claims are the parts of an access token the app relies on.
+type Claims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+}
+
+// MakeJWT issues an access token for a session. The session ID goes in the
+// jti claim so a revoked session can be refused.
+func MakeJWT(userID, sessionID uuid.UUID, expiresIn time.Duration, secretKey string) (string, error) {
 	signature := []byte(secretKey)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
 		Issuer:    ISSUER,
 		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
 		Subject:   userID.String(),
+		ID:        sessionID.String(),
 	})
 	return token.SignedString(signature)
 }
 
-func VerifyJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+func VerifyJWT(tokenString, tokenSecret string) (Claims, error) {
 	claims := jwt.RegisteredClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
 		return []byte(tokenSecret), nil
 	})
+	if err != nil {
+		return Claims{}, err
+	}
 	if !token.Valid {
-		return uuid.Nil, errors.New("invalid token")
+		return Claims{}, errors.New("invalid token")
+	}
+	if claims.Issuer != ISSUER {
+		return Claims{}, errors.New("invalid issuer")
 	}
+	if len(claims.Audience) > 0 {
+		return Claims{}, errors.New("not an access token")
+	}
+	userId, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return Claims{}, err
 	}
-	uid, err := token.Claims.GetSubject()
+	sessionID, err := uuid.Parse(claims.ID)
 	if err != nil {
-		return uuid.Nil, err
+		return Claims{}, err
 	}
-	issuer, err := token.Claims.GetIssuer()
+	return Claims{UserID: userId, SessionID: sessionID}, nil
+}
+
+// MakeMFAToken issues the short-lived token a user holds between entering
+// their password and their second factor. It is not accepted as an access
+// token.
+func MakeMFAToken(userID uuid.UUID, expiresIn time.Duration, secretKey string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    ISSUER,
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+		Subject:   userID.String(),
+	})
+	return token.SignedString([]byte(secretKey))
+}
+
+func VerifyMFAToken(tokenString, tokenSecret string) (uuid.UUID, error) {
+	claims := jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
+		return []byte(tokenSecret), nil
+	}, jwt.WithIssuer(ISSUER), jwt.WithAudience(mfaAudience))
 	if err != nil {
 		return uuid.Nil, err
 	}
-	if issuer != ISSUER {
-		return uuid.Nil, errors.New("invalid issuer")
+	return uuid.Parse(claims.Subject)
+}
+
+// MakeInvitationToken signs the link emailed with an organization
+// invitation. The invitation row is still checked on use, so revoking it
+// disables the link.
+func MakeInvitationToken(invitationID int64, expiresAt time.Time, secretKey string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    ISSUER,
+		Audience:  jwt.ClaimStrings{invitationAudience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		ExpiresAt: jwt.NewNumericDate(expiresAt.UTC()),
+		Subject:   strconv.FormatInt(invitationID, 10),
+	})
+	return token.SignedString([]byte(secretKey))
+}
+
+// VerifyInvitationToken returns the invitation ID from a signed link, or
+// ErrExpiredToken once the link has expired.
+func VerifyInvitationToken(tokenString, tokenSecret string) (int64, error) {
+	claims := jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
+		return []byte(tokenSecret), nil
+	}, jwt.WithIssuer(ISSUER), jwt.WithAudience(invitationAudience))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return 0, ErrExpiredToken
 	}
-	userId, err := uuid.Parse(uid)
 	if err != nil {
-		return uuid.Nil, err
+		return 0, err
 	}
-	return userId, nil
+	return strconv.ParseInt(claims.Subject, 10, 64)
 }
 
 func GenerateVerificationToken() (string, error) {
@@ -74,3 +169,21 @@ func GenerateVerificationToken() (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// GenerateAPIKey returns a new personal API key and the prefix shown in the
+// UI to tell keys apart.
+func GenerateAPIKey() (key, prefix string, err error) {
+	token, err := GenerateVerificationToken()
+	if err != nil {
+		return "", "", err
+	}
+	key = "mh_" + token
+	return key, key[:11], nil
+}
+
+// HashToken is used to store emailed tokens, so a leaked table can't be used
+// to take over accounts. The tokens are random, so a fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,5 +1,6 @@
 package auth
 
+// What each plan includes is configured in the entitlements registry.
 const (
 	PlanFree  = "free"
 	PlanPro   = "pro"
@@ -0,0 +1,9 @@
+package auth
+
+const (
+	ScopeContactsRead  = "contacts:read"
+	ScopeContactsWrite = "contacts:write"
+)
+
+// Scopes lists every scope an API key can be given.
+var Scopes = []string{ScopeContactsRead, ScopeContactsWrite}
@@ -0,0 +1,70 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/stripe/stripe-go/v82"
+)
+
+var ErrNotConfigured = errors.New("stripe is not configured")
+
+// Stripe creates Checkout Sessions for upgrading to the Pro plan.
+type Stripe struct {
+	Client     *stripe.Client
+	ProPriceID string
+}
+
+// NewStripeFromEnv uses STRIPE_SECRET_KEY and the price in
+// STRIPE_PRO_PRICE_ID. STRIPE_API_BASE points the client at another server,
+// such as a local fake of the Stripe API.
+func NewStripeFromEnv() *Stripe {
+	key := os.Getenv("STRIPE_SECRET_KEY")
+
+	var opts []stripe.ClientOption
+	if base := os.Getenv("STRIPE_API_BASE"); base != "" {
+		opts = append(opts, stripe.WithBackends(stripe.NewBackendsWithConfig(&stripe.BackendConfig{
+			URL: stripe.String(base),
+		})))
+	}
+
+	return &Stripe{
+		Client:     stripe.NewClient(key, opts...),
+		ProPriceID: os.Getenv("STRIPE_PRO_PRICE_ID"),
+	}
+}
+
+// CreateCheckoutSession starts a Pro subscription checkout for the user. The
+// session carries the user's ID as client_reference_id, which is how the
+// webhook finds them again, and reuses their Stripe customer if they have
+// one so Stripe doesn't create duplicates.
+func (s *Stripe) CreateCheckoutSession(ctx context.Context, user database.User, successURL, cancelURL string) (*stripe.CheckoutSession, error) {
+	if s.ProPriceID == "" {
+		return nil, ErrNotConfigured
+	}
+
+	params := &stripe.CheckoutSessionCreateParams{
+		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		ClientReferenceID: stripe.String(user.ID.String()),
+		SuccessURL:        stripe.String(successURL),
+		CancelURL:         stripe.String(cancelURL),
+		LineItems: []*stripe.CheckoutSessionCreateLineItemParams{
+			{
+				Price:    stripe.String(s.ProPriceID),
+				Quantity: stripe.Int64(1),
+			},
+		},
+		SubscriptionData: &stripe.CheckoutSessionCreateSubscriptionDataParams{
+			Metadata: map[string]string{"user_id": user.ID.String()},
+		},
+	}
+	if user.StripeCustomerID.Valid && user.StripeCustomerID.String != "" {
+		params.Customer = stripe.String(user.StripeCustomerID.String)
+	} else {
+		params.CustomerEmail = stripe.String(user.Email)
+	}
+
+	return s.Client.V1CheckoutSessions.Create(ctx, params)
+}
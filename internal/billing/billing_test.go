@@ -0,0 +1,104 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+// fakeStripe answers Checkout Session creation like the Stripe API does and
+// keeps the form of the last request.
+func fakeStripe(t *testing.T) *url.Values {
+	t.Helper()
+
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/checkout/sessions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if form, err = url.ParseQuery(string(body)); err != nil {
+			t.Error(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": "cs_test_1", "object": "checkout.session", "url": "https://checkout.stripe.com/c/pay/cs_test_1"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("STRIPE_API_BASE", srv.URL)
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_fake")
+	t.Setenv("STRIPE_PRO_PRICE_ID", "price_pro")
+	return &form
+}
+
+func TestCreateCheckoutSession(t *testing.T) {
+	user := database.User{ID: uuid.New(), Email: "ada@example.com"}
+
+	t.Run("new customer", func(t *testing.T) {
+		form := fakeStripe(t)
+
+		session, err := NewStripeFromEnv().CreateCheckoutSession(context.Background(), user, "https://app.test/ok", "https://app.test/plans")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.URL != "https://checkout.stripe.com/c/pay/cs_test_1" {
+			t.Errorf("URL = %q", session.URL)
+		}
+
+		for key, want := range map[string]string{
+			"client_reference_id":                  user.ID.String(),
+			"customer_email":                       user.Email,
+			"customer":                             "",
+			"mode":                                 "subscription",
+			"line_items[0][price]":                 "price_pro",
+			"subscription_data[metadata][user_id]": user.ID.String(),
+		} {
+			if got := form.Get(key); got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+	})
+
+	t.Run("existing customer", func(t *testing.T) {
+		form := fakeStripe(t)
+
+		user := user
+		user.StripeCustomerID = sql.NullString{String: "cus_123", Valid: true}
+		if _, err := NewStripeFromEnv().CreateCheckoutSession(context.Background(), user, "https://app.test/ok", "https://app.test/plans"); err != nil {
+			t.Fatal(err)
+		}
+
+		for key, want := range map[string]string{
+			"client_reference_id": user.ID.String(),
+			"customer":            "cus_123",
+			"customer_email":      "",
+		} {
+			if got := form.Get(key); got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+	})
+
+	t.Run("no price", func(t *testing.T) {
+		fakeStripe(t)
+		t.Setenv("STRIPE_PRO_PRICE_ID", "")
+
+		if _, err := NewStripeFromEnv().CreateCheckoutSession(context.Background(), user, "", ""); !errors.Is(err, ErrNotConfigured) {
+			t.Errorf("err = %v, want ErrNotConfigured", err)
+		}
+	})
+}
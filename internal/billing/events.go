@@ -0,0 +1,230 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v82"
+)
+
+// Stripe event statuses
+const (
+	EventPending   = "pending"
+	EventProcessed = "processed"
+	EventFailed    = "failed"
+)
+
+const (
+	// MaxEventAttempts is how often an event is processed before it is marked
+	// failed for an admin to look at.
+	MaxEventAttempts = 8
+
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = 6 * time.Hour
+
+	// eventLease keeps other workers away from a claimed event. It only
+	// matters if a worker dies mid-event, after which the event is retried.
+	eventLease = 5 * time.Minute
+)
+
+// errMalformedEvent marks events that can't be decoded. Retrying won't help,
+// so they fail straight away.
+var errMalformedEvent = errors.New("malformed stripe event")
+
+// RecordEvent stores a verified webhook event for the worker to process. It
+// reports false if the event was already stored, which happens when Stripe
+// delivers it again.
+func RecordEvent(ctx context.Context, db *database.Queries, event stripe.Event, payload []byte) (bool, error) {
+	stored, err := db.InsertStripeEvent(ctx, database.InsertStripeEventParams{
+		ID:              event.ID,
+		Type:            string(event.Type),
+		Payload:         payload,
+		StripeCreatedAt: time.Unix(event.Created, 0),
+	})
+	return stored > 0, err
+}
+
+// EventProcessor applies stored Stripe events. Users' plans are derived from
+// their subscriptions, never set directly from an event.
+type EventProcessor struct {
+	Conn    *sql.DB
+	DB      *database.Queries
+	Mailer  *email.Mailer
+	AppHost string
+}
+
+// ProcessDue processes up to batchSize due events, oldest first by the time
+// Stripe created them. Failed events are retried with exponential backoff and
+// marked failed after MaxEventAttempts. An event waiting for a retry doesn't
+// hold back newer ones; subscriptions ignore state older than what they
+// store, so applying them out of order is safe.
+func (p *EventProcessor) ProcessDue(ctx context.Context, batchSize int32) (processed, failed int, err error) {
+	events, err := p.DB.ClaimDueStripeEvents(ctx, database.ClaimDueStripeEventsParams{
+		LeaseUntil: time.Now().Add(eventLease),
+		BatchSize:  batchSize,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, e := range events {
+		processErr := p.process(ctx, e)
+		if processErr == nil {
+			processed++
+			continue
+		}
+
+		failed++
+		lastError := sql.NullString{String: processErr.Error(), Valid: true}
+		if e.Attempts+1 >= MaxEventAttempts || errors.Is(processErr, errMalformedEvent) {
+			log.Printf("Giving up on Stripe event %s (%s) after %d attempts: %v", e.ID, e.Type, e.Attempts+1, processErr)
+			err = p.DB.FailStripeEvent(ctx, database.FailStripeEventParams{ID: e.ID, LastError: lastError})
+		} else {
+			err = p.DB.RetryStripeEventLater(ctx, database.RetryStripeEventLaterParams{
+				ID:            e.ID,
+				LastError:     lastError,
+				NextAttemptAt: time.Now().Add(retryDelay(e.Attempts + 1)),
+			})
+		}
+		if err != nil {
+			log.Printf("Error recording failure of Stripe event %s: %v", e.ID, err)
+		}
+	}
+	return processed, failed, nil
+}
+
+// Replay processes a stored event again right away, whatever its status. A
+// failed replay leaves the stored event as it was.
+func (p *EventProcessor) Replay(ctx context.Context, id string) error {
+	e, err := p.DB.GetStripeEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+	return p.process(ctx, e)
+}
+
+// process applies one event and marks it processed in a single transaction,
+// so its changes and emails happen exactly once.
+func (p *EventProcessor) process(ctx context.Context, e database.StripeEvent) error {
+	var event stripe.Event
+	if err := json.Unmarshal(e.Payload, &event); err != nil {
+		return fmt.Errorf("%w: %v", errMalformedEvent, err)
+	}
+
+	tx, err := p.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := p.DB.WithTx(tx)
+
+	if err := p.handle(ctx, qtx, event); err != nil {
+		return err
+	}
+	if err := qtx.MarkStripeEventProcessed(ctx, e.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *EventProcessor) handle(ctx context.Context, db *database.Queries, event stripe.Event) error {
+	plansLink := p.AppHost + "/plans"
+	mailer := p.Mailer.Outbox(ctx, db)
+
+	switch event.Type {
+	case "checkout.session.completed":
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			return fmt.Errorf("%w: %v", errMalformedEvent, err)
+		}
+
+		// Sessions from /billing/checkout carry the user's ID, so the email
+		// paid with doesn't matter
+		userID, err := uuid.Parse(session.ClientReferenceID)
+		if err != nil {
+			log.Printf("Checkout session %s has no user reference", session.ID)
+			return nil
+		}
+
+		log.Printf("Checkout completed for user %s", userID)
+
+		// The plan changes with the subscription events, this only links
+		// the customer for later events
+		if session.Customer == nil {
+			return nil
+		}
+		return db.UpdateStripeCustomerID(ctx, database.UpdateStripeCustomerIDParams{
+			ID:               userID,
+			StripeCustomerID: sql.NullString{String: session.Customer.ID, Valid: true},
+		})
+
+	case "customer.subscription.created",
+		"customer.subscription.updated",
+		"customer.subscription.deleted",
+		"customer.subscription.trial_will_end":
+		var sub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &sub); err != nil {
+			return fmt.Errorf("%w: %v", errMalformedEvent, err)
+		}
+
+		user, err := SyncSubscription(ctx, db, &sub, time.Unix(event.Created, 0))
+		if errors.Is(err, ErrUnknownCustomer) {
+			log.Printf("No user for subscription %s", sub.ID)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("sync subscription %s: %w", sub.ID, err)
+		}
+
+		log.Printf("Subscription %s is %s, user %s is on the %s plan", sub.ID, sub.Status, user.ID, user.Plan)
+
+		switch {
+		case event.Type == "customer.subscription.deleted" && user.Plan == auth.PlanFree:
+			return mailer.SendSubscriptionCanceledEmail(user.Email, user.FirstName, plansLink)
+		case event.Type == "customer.subscription.trial_will_end" && sub.TrialEnd != 0:
+			return mailer.SendTrialEndingEmail(user.Email, user.FirstName, plansLink, time.Unix(sub.TrialEnd, 0))
+		}
+
+	case "invoice.payment_failed":
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return fmt.Errorf("%w: %v", errMalformedEvent, err)
+		}
+
+		// The subscription turns past_due through
+		// customer.subscription.updated; this only tells the user
+		user, err := UserForCustomer(ctx, db, invoice.Customer)
+		if errors.Is(err, ErrUnknownCustomer) {
+			log.Printf("No user for failed invoice %s", invoice.ID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Payment failed for user %s", user.ID)
+		return mailer.SendPaymentFailedEmail(user.Email, user.FirstName, plansLink)
+
+	default:
+		log.Printf("Unhandled event type: %s", event.Type)
+	}
+	return nil
+}
+
+// retryDelay doubles the wait after every failed attempt, up to retryMaxDelay.
+func retryDelay(attempts int32) time.Duration {
+	d := retryBaseDelay << (attempts - 1)
+	if d <= 0 || d > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return d
+}
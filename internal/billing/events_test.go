@@ -0,0 +1,137 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/dbtest"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v82"
+)
+
+// checkoutCompleted is a checkout.session.completed event paid for with an
+// email that isn't the user's.
+func checkoutCompleted(t *testing.T, userID uuid.UUID) (stripe.Event, []byte) {
+	t.Helper()
+
+	payload := fmt.Sprintf(`{
+		"id": "evt_checkout_1",
+		"object": "event",
+		"type": "checkout.session.completed",
+		"created": %d,
+		"data": {"object": {
+			"id": "cs_test_1",
+			"object": "checkout.session",
+			"client_reference_id": %q,
+			"customer": "cus_123",
+			"customer_details": {"email": "accounts@othercorp.com"}
+		}}
+	}`, time.Now().Unix(), userID)
+
+	var event stripe.Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	return event, []byte(payload)
+}
+
+// execRecorder is a DBTX that only allows statements without results.
+type execRecorder struct {
+	t     *testing.T
+	execs [][]any
+}
+
+func (d *execRecorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	d.execs = append(d.execs, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (d *execRecorder) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	d.t.Fatalf("unexpected prepare: %s", query)
+	return nil, nil
+}
+
+func (d *execRecorder) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	d.t.Fatalf("unexpected query: %s", query)
+	return nil, nil
+}
+
+func (d *execRecorder) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	d.t.Fatalf("unexpected query: %s", query)
+	return nil
+}
+
+func TestCheckoutCompletedUsesClientReference(t *testing.T) {
+	userID := uuid.New()
+	event, _ := checkoutCompleted(t, userID)
+
+	db := &execRecorder{t: t}
+	p := &EventProcessor{Mailer: &email.Mailer{}}
+	if err := p.handle(context.Background(), database.New(db), event); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the customer is linked, to the referenced user; nobody is looked
+	// up by the email paid with
+	if len(db.execs) != 1 {
+		t.Fatalf("got %d statements, want 1", len(db.execs))
+	}
+	args := db.execs[0]
+	if args[0] != userID || args[1] != (sql.NullString{String: "cus_123", Valid: true}) {
+		t.Errorf("linked %v", args)
+	}
+}
+
+func TestProcessCheckoutCompleted(t *testing.T) {
+	conn := dbtest.Open(t)
+	db := database.New(conn)
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{
+		Username: "ada", Email: "ada@example.com", FirstName: "Ada", PasswordHash: "x", Role: "user", Plan: "free",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Someone else signed up with the email the checkout was paid with
+	other, err := db.CreateUser(ctx, database.CreateUserParams{
+		Username: "accounts", Email: "accounts@othercorp.com", FirstName: "Accounts", PasswordHash: "x", Role: "user", Plan: "free",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, payload := checkoutCompleted(t, user.ID)
+	if _, err := RecordEvent(ctx, db, event, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &EventProcessor{Conn: conn, DB: db, Mailer: &email.Mailer{}}
+	processed, failed, err := p.ProcessDue(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != 1 || failed != 0 {
+		t.Fatalf("processed %d, failed %d; want 1 processed", processed, failed)
+	}
+
+	if user, err = db.GetUserByID(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if user.StripeCustomerID.String != "cus_123" {
+		t.Errorf("user's customer = %q, want cus_123", user.StripeCustomerID.String)
+	}
+	if other, err = db.GetUserByID(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if other.StripeCustomerID.Valid {
+		t.Errorf("user with the payer's email got customer %q", other.StripeCustomerID.String)
+	}
+}
@@ -0,0 +1,99 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v82"
+)
+
+var ErrUnknownCustomer = errors.New("no user for stripe customer")
+
+// UserForCustomer finds the user a Stripe customer belongs to.
+func UserForCustomer(ctx context.Context, db *database.Queries, customer *stripe.Customer) (database.User, error) {
+	if customer == nil || customer.ID == "" {
+		return database.User{}, ErrUnknownCustomer
+	}
+	user, err := db.GetUserByStripeCustomerID(ctx, sql.NullString{String: customer.ID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUnknownCustomer
+	}
+	return user, err
+}
+
+// userForSubscription prefers the user ID that checkout puts in the
+// subscription metadata, since subscription events can arrive before
+// checkout.session.completed has stored the customer ID.
+func userForSubscription(ctx context.Context, db *database.Queries, sub *stripe.Subscription) (database.User, error) {
+	userID, err := uuid.Parse(sub.Metadata["user_id"])
+	if err != nil {
+		return UserForCustomer(ctx, db, sub.Customer)
+	}
+
+	user, err := db.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUnknownCustomer
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+
+	if !user.StripeCustomerID.Valid && sub.Customer != nil {
+		user.StripeCustomerID = sql.NullString{String: sub.Customer.ID, Valid: true}
+		err = db.UpdateStripeCustomerID(ctx, database.UpdateStripeCustomerIDParams{
+			ID:               user.ID,
+			StripeCustomerID: user.StripeCustomerID,
+		})
+	}
+	return user, err
+}
+
+// SyncSubscription stores the state of a subscription as of a Stripe event
+// and derives the owner's plan from their subscriptions. Events older than
+// the stored state are ignored. It returns the user with their current plan.
+func SyncSubscription(ctx context.Context, db *database.Queries, sub *stripe.Subscription, eventAt time.Time) (database.User, error) {
+	user, err := userForSubscription(ctx, db, sub)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	params := database.UpsertSubscriptionParams{
+		ID:                sub.ID,
+		UserID:            user.ID,
+		StripeCustomerID:  user.StripeCustomerID.String,
+		Status:            string(sub.Status),
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		TrialEnd:          unixTime(sub.TrialEnd),
+		CanceledAt:        unixTime(sub.CanceledAt),
+		StripeEventAt:     eventAt,
+	}
+	if sub.Customer != nil {
+		params.StripeCustomerID = sub.Customer.ID
+	}
+	// The app sells a single price, so the first item describes the plan
+	if sub.Items != nil && len(sub.Items.Data) > 0 {
+		item := sub.Items.Data[0]
+		params.CurrentPeriodEnd = unixTime(item.CurrentPeriodEnd)
+		if item.Price != nil {
+			params.PriceID = sql.NullString{String: item.Price.ID, Valid: true}
+		}
+	}
+
+	if _, err := db.UpsertSubscription(ctx, params); err != nil {
+		return database.User{}, err
+	}
+
+	user.Plan, err = db.SyncUserPlan(ctx, user.ID)
+	return user, err
+}
+
+func unixTime(t int64) sql.NullTime {
+	if t == 0 {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: time.Unix(t, 0), Valid: true}
+}
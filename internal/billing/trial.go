@@ -0,0 +1,39 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+)
+
+const (
+	// TrialLength is how long a Pro trial lasts.
+	TrialLength = 14 * 24 * time.Hour
+
+	// TrialReminderLead is how long before a trial ends the worker emails a
+	// reminder.
+	TrialReminderLead = 3 * 24 * time.Hour
+)
+
+var ErrTrialUnavailable = errors.New("trial is not available")
+
+// StartTrial gives a free user Pro features for TrialLength. Each user gets a
+// single trial, and subscribers can't start one. It returns when the trial
+// ends.
+func StartTrial(ctx context.Context, db *database.Queries, user database.User) (time.Time, error) {
+	endsAt := time.Now().Add(TrialLength)
+	started, err := db.StartTrial(ctx, database.StartTrialParams{
+		ID:          user.ID,
+		TrialEndsAt: sql.NullTime{Time: endsAt, Valid: true},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if started == 0 {
+		return time.Time{}, ErrTrialUnavailable
+	}
+	return endsAt, nil
+}
@@ -8,17 +8,20 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const countContactsByUser = `-- name: CountContactsByUser :one
+const countContactsCreatedBy = `-- name: CountContactsCreatedBy :one
 SELECT COUNT(*) FROM contacts
-WHERE user_id = $1
+WHERE created_by = $1
+  AND deleted_at IS NULL
 `
 
-func (q *Queries) CountContactsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countContactsByUser, userID)
+func (q *Queries) CountContactsCreatedBy(ctx context.Context, createdBy uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countContactsCreatedBy, createdBy)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -26,25 +29,29 @@ func (q *Queries) CountContactsByUser(ctx context.Context, userID uuid.UUID) (in
 
 const createContact = `-- name: CreateContact :one
 INSERT INTO contacts (
-    user_id, name, email, phone, company, position, notes
+    organization_id, owner_id, created_by, name, email, phone, company, position, notes
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, name, email, phone, company, position, notes, created_at, updated_at
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked
 `
 
 type CreateContactParams struct {
-	UserID   uuid.UUID
-	Name     string
-	Email    sql.NullString
-	Phone    sql.NullString
-	Company  sql.NullString
-	Position sql.NullString
-	Notes    sql.NullString
+	OrganizationID uuid.UUID
+	OwnerID        uuid.NullUUID
+	CreatedBy      uuid.NullUUID
+	Name           string
+	Email          sql.NullString
+	Phone          sql.NullString
+	Company        sql.NullString
+	Position       sql.NullString
+	Notes          sql.NullString
 }
 
 func (q *Queries) CreateContact(ctx context.Context, arg CreateContactParams) (Contact, error) {
 	row := q.db.QueryRowContext(ctx, createContact,
-		arg.UserID,
+		arg.OrganizationID,
+		arg.OwnerID,
+		arg.CreatedBy,
 		arg.Name,
 		arg.Email,
 		arg.Phone,
@@ -55,7 +62,7 @@ func (q *Queries) CreateContact(ctx context.Context, arg CreateContactParams) (C
 	var i Contact
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.OwnerID,
 		&i.Name,
 		&i.Email,
 		&i.Phone,
@@ -64,41 +71,47 @@ func (q *Queries) CreateContact(ctx context.Context, arg CreateContactParams) (C
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CompanyID,
+		&i.DeletedAt,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CompanyAutoLinked,
 	)
 	return i, err
 }
 
 const deleteContact = `-- name: DeleteContact :exec
 DELETE FROM contacts
-WHERE id = $1 AND user_id = $2
+WHERE id = $1 AND organization_id = $2
 `
 
 type DeleteContactParams struct {
-	ID     int64
-	UserID uuid.UUID
+	ID             int64
+	OrganizationID uuid.UUID
 }
 
 func (q *Queries) DeleteContact(ctx context.Context, arg DeleteContactParams) error {
-	_, err := q.db.ExecContext(ctx, deleteContact, arg.ID, arg.UserID)
+	_, err := q.db.ExecContext(ctx, deleteContact, arg.ID, arg.OrganizationID)
 	return err
 }
 
 const getContactByID = `-- name: GetContactByID :one
-SELECT id, user_id, name, email, phone, company, position, notes, created_at, updated_at FROM contacts
-WHERE id = $1 AND user_id = $2
+SELECT id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked FROM contacts
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NULL
 `
 
 type GetContactByIDParams struct {
-	ID     int64
-	UserID uuid.UUID
+	ID             int64
+	OrganizationID uuid.UUID
 }
 
 func (q *Queries) GetContactByID(ctx context.Context, arg GetContactByIDParams) (Contact, error) {
-	row := q.db.QueryRowContext(ctx, getContactByID, arg.ID, arg.UserID)
+	row := q.db.QueryRowContext(ctx, getContactByID, arg.ID, arg.OrganizationID)
 	var i Contact
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.OwnerID,
 		&i.Name,
 		&i.Email,
 		&i.Phone,
@@ -107,17 +120,29 @@ func (q *Queries) GetContactByID(ctx context.Context, arg GetContactByIDParams)
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CompanyID,
+		&i.DeletedAt,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CompanyAutoLinked,
 	)
 	return i, err
 }
 
-const getContactsByUser = `-- name: GetContactsByUser :many
-SELECT id, user_id, name, email, phone, company, position, notes, created_at, updated_at FROM contacts
-WHERE user_id = $1
+const getContactsByCompany = `-- name: GetContactsByCompany :many
+SELECT id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked FROM contacts
+WHERE company_id = $1 AND organization_id = $2
+  AND deleted_at IS NULL
+ORDER BY name
 `
 
-func (q *Queries) GetContactsByUser(ctx context.Context, userID uuid.UUID) ([]Contact, error) {
-	rows, err := q.db.QueryContext(ctx, getContactsByUser, userID)
+type GetContactsByCompanyParams struct {
+	CompanyID      sql.NullInt64
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) GetContactsByCompany(ctx context.Context, arg GetContactsByCompanyParams) ([]Contact, error) {
+	rows, err := q.db.QueryContext(ctx, getContactsByCompany, arg.CompanyID, arg.OrganizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +152,7 @@ func (q *Queries) GetContactsByUser(ctx context.Context, userID uuid.UUID) ([]Co
 		var i Contact
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.OwnerID,
 			&i.Name,
 			&i.Email,
 			&i.Phone,
@@ -136,6 +161,56 @@ func (q *Queries) GetContactsByUser(ctx context.Context, userID uuid.UUID) ([]Co
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CompanyID,
+			&i.DeletedAt,
+			&i.OrganizationID,
+			&i.CreatedBy,
+			&i.CompanyAutoLinked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getContactsByOrganization = `-- name: GetContactsByOrganization :many
+SELECT id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked FROM contacts
+WHERE organization_id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) GetContactsByOrganization(ctx context.Context, organizationID uuid.UUID) ([]Contact, error) {
+	rows, err := q.db.QueryContext(ctx, getContactsByOrganization, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.Email,
+			&i.Phone,
+			&i.Company,
+			&i.Position,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompanyID,
+			&i.DeletedAt,
+			&i.OrganizationID,
+			&i.CreatedBy,
+			&i.CompanyAutoLinked,
 		); err != nil {
 			return nil, err
 		}
@@ -151,9 +226,10 @@ func (q *Queries) GetContactsByUser(ctx context.Context, userID uuid.UUID) ([]Co
 }
 
 const getContactsPaginated = `-- name: GetContactsPaginated :many
-SELECT id, user_id, name, email, phone, company, position, notes, created_at, updated_at
+SELECT id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked
 FROM contacts
-WHERE user_id = $1
+WHERE organization_id = $1
+  AND deleted_at IS NULL
   AND id > $2
   AND (
     $3::text IS NULL OR
@@ -173,30 +249,59 @@ WHERE user_id = $1
   AND (
     NOT $7::bool OR (email IS NOT NULL AND email <> '')
   )
+  AND (
+    coalesce(cardinality($8::text[]), 0) = 0 OR (
+      SELECT COUNT(DISTINCT tags.name)
+      FROM contact_tags
+      JOIN tags ON tags.id = contact_tags.tag_id
+      WHERE contact_tags.contact_id = contacts.id
+        AND tags.name = ANY($8::text[])
+    ) >= CASE WHEN $9::bool THEN cardinality($8::text[]) ELSE 1 END
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($10::text[], $11::text[]) AS filter(key, value)
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM contact_custom_values
+      JOIN custom_fields ON custom_fields.id = contact_custom_values.field_id
+      WHERE contact_custom_values.contact_id = contacts.id
+        AND custom_fields.key = filter.key
+        AND contact_custom_values.value = filter.value
+    )
+  )
 ORDER BY id
-LIMIT $8
+LIMIT $12
 `
 
 type GetContactsPaginatedParams struct {
-	UserID                  uuid.UUID
+	OrganizationID          uuid.UUID
 	After                   int64
 	Search                  string
 	RequireNonEmptyPhone    bool
 	RequireNonEmptyCompany  bool
 	RequireNonEmptyPosition bool
 	RequireNonEmptyEmail    bool
+	Tags                    []string
+	MatchAllTags            bool
+	CustomFieldKeys         []string
+	CustomFieldValues       []string
 	Limit                   int32
 }
 
 func (q *Queries) GetContactsPaginated(ctx context.Context, arg GetContactsPaginatedParams) ([]Contact, error) {
 	rows, err := q.db.QueryContext(ctx, getContactsPaginated,
-		arg.UserID,
+		arg.OrganizationID,
 		arg.After,
 		arg.Search,
 		arg.RequireNonEmptyPhone,
 		arg.RequireNonEmptyCompany,
 		arg.RequireNonEmptyPosition,
 		arg.RequireNonEmptyEmail,
+		pq.Array(arg.Tags),
+		arg.MatchAllTags,
+		pq.Array(arg.CustomFieldKeys),
+		pq.Array(arg.CustomFieldValues),
 		arg.Limit,
 	)
 	if err != nil {
@@ -208,7 +313,7 @@ func (q *Queries) GetContactsPaginated(ctx context.Context, arg GetContactsPagin
 		var i Contact
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.OwnerID,
 			&i.Name,
 			&i.Email,
 			&i.Phone,
@@ -217,6 +322,11 @@ func (q *Queries) GetContactsPaginated(ctx context.Context, arg GetContactsPagin
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CompanyID,
+			&i.DeletedAt,
+			&i.OrganizationID,
+			&i.CreatedBy,
+			&i.CompanyAutoLinked,
 		); err != nil {
 			return nil, err
 		}
@@ -231,6 +341,227 @@ func (q *Queries) GetContactsPaginated(ctx context.Context, arg GetContactsPagin
 	return items, nil
 }
 
+const getTrashedContactByID = `-- name: GetTrashedContactByID :one
+SELECT id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked FROM contacts
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NOT NULL
+`
+
+type GetTrashedContactByIDParams struct {
+	ID             int64
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) GetTrashedContactByID(ctx context.Context, arg GetTrashedContactByIDParams) (Contact, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedContactByID, arg.ID, arg.OrganizationID)
+	var i Contact
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Company,
+		&i.Position,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompanyID,
+		&i.DeletedAt,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CompanyAutoLinked,
+	)
+	return i, err
+}
+
+const getTrashedContacts = `-- name: GetTrashedContacts :many
+SELECT id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked FROM contacts
+WHERE organization_id = $1
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) GetTrashedContacts(ctx context.Context, organizationID uuid.UUID) ([]Contact, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedContacts, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.Email,
+			&i.Phone,
+			&i.Company,
+			&i.Position,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompanyID,
+			&i.DeletedAt,
+			&i.OrganizationID,
+			&i.CreatedBy,
+			&i.CompanyAutoLinked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockContactLimit = `-- name: LockContactLimit :exec
+SELECT pg_advisory_xact_lock(hashtextextended('contacts_limit:' || $1::uuid::text, 0))
+`
+
+func (q *Queries) LockContactLimit(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockContactLimit, userID)
+	return err
+}
+
+const purgeContact = `-- name: PurgeContact :execrows
+DELETE FROM contacts
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NOT NULL
+`
+
+type PurgeContactParams struct {
+	ID             int64
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) PurgeContact(ctx context.Context, arg PurgeContactParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeContact, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeTrashedContacts = `-- name: PurgeTrashedContacts :execrows
+DELETE FROM contacts
+WHERE deleted_at IS NOT NULL
+  AND deleted_at < $1::timestamptz
+`
+
+func (q *Queries) PurgeTrashedContacts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeTrashedContacts, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const releaseContactsOwnedBy = `-- name: ReleaseContactsOwnedBy :exec
+UPDATE contacts
+SET owner_id = NULL,
+    updated_at = NOW()
+WHERE organization_id = $1 AND owner_id = $2
+`
+
+type ReleaseContactsOwnedByParams struct {
+	OrganizationID uuid.UUID
+	OwnerID        uuid.NullUUID
+}
+
+func (q *Queries) ReleaseContactsOwnedBy(ctx context.Context, arg ReleaseContactsOwnedByParams) error {
+	_, err := q.db.ExecContext(ctx, releaseContactsOwnedBy, arg.OrganizationID, arg.OwnerID)
+	return err
+}
+
+const restoreContact = `-- name: RestoreContact :one
+UPDATE contacts
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NOT NULL
+RETURNING id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked
+`
+
+type RestoreContactParams struct {
+	ID             int64
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) RestoreContact(ctx context.Context, arg RestoreContactParams) (Contact, error) {
+	row := q.db.QueryRowContext(ctx, restoreContact, arg.ID, arg.OrganizationID)
+	var i Contact
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Company,
+		&i.Position,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompanyID,
+		&i.DeletedAt,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CompanyAutoLinked,
+	)
+	return i, err
+}
+
+const setContactCompany = `-- name: SetContactCompany :exec
+UPDATE contacts
+SET company_id = $3,
+    company_auto_linked = $4,
+    updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+`
+
+type SetContactCompanyParams struct {
+	ID                int64
+	OrganizationID    uuid.UUID
+	CompanyID         sql.NullInt64
+	CompanyAutoLinked bool
+}
+
+func (q *Queries) SetContactCompany(ctx context.Context, arg SetContactCompanyParams) error {
+	_, err := q.db.ExecContext(ctx, setContactCompany,
+		arg.ID,
+		arg.OrganizationID,
+		arg.CompanyID,
+		arg.CompanyAutoLinked,
+	)
+	return err
+}
+
+const softDeleteContact = `-- name: SoftDeleteContact :execrows
+UPDATE contacts
+SET deleted_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND deleted_at IS NULL
+`
+
+type SoftDeleteContactParams struct {
+	ID             int64
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) SoftDeleteContact(ctx context.Context, arg SoftDeleteContactParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteContact, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateContact = `-- name: UpdateContact :one
 UPDATE contacts
 SET name = $3,
@@ -239,37 +570,40 @@ SET name = $3,
     company = $6,
     position = $7,
     notes = $8,
+    owner_id = $9,
     updated_at = NOW()
-WHERE id = $1 AND user_id = $2
-RETURNING id, user_id, name, email, phone, company, position, notes, created_at, updated_at
+WHERE id = $1 AND organization_id = $2
+RETURNING id, owner_id, name, email, phone, company, position, notes, created_at, updated_at, company_id, deleted_at, organization_id, created_by, company_auto_linked
 `
 
 type UpdateContactParams struct {
-	ID       int64
-	UserID   uuid.UUID
-	Name     string
-	Email    sql.NullString
-	Phone    sql.NullString
-	Company  sql.NullString
-	Position sql.NullString
-	Notes    sql.NullString
+	ID             int64
+	OrganizationID uuid.UUID
+	Name           string
+	Email          sql.NullString
+	Phone          sql.NullString
+	Company        sql.NullString
+	Position       sql.NullString
+	Notes          sql.NullString
+	OwnerID        uuid.NullUUID
 }
 
 func (q *Queries) UpdateContact(ctx context.Context, arg UpdateContactParams) (Contact, error) {
 	row := q.db.QueryRowContext(ctx, updateContact,
 		arg.ID,
-		arg.UserID,
+		arg.OrganizationID,
 		arg.Name,
 		arg.Email,
 		arg.Phone,
 		arg.Company,
 		arg.Position,
 		arg.Notes,
+		arg.OwnerID,
 	)
 	var i Contact
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.OwnerID,
 		&i.Name,
 		&i.Email,
 		&i.Phone,
@@ -278,6 +612,11 @@ func (q *Queries) UpdateContact(ctx context.Context, arg UpdateContactParams) (C
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CompanyID,
+		&i.DeletedAt,
+		&i.OrganizationID,
+		&i.CreatedBy,
+		&i.CompanyAutoLinked,
 	)
 	return i, err
 }
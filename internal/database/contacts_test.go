@@ -0,0 +1,76 @@
+package database_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/dbtest"
+)
+
+func TestGetContactsPaginatedTags(t *testing.T) {
+	ctx := context.Background()
+	db := database.New(dbtest.Open(t))
+
+	org, err := db.CreateOrganization(ctx, "Acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contacts := map[string][]string{
+		"Both":     {"lead", "customer"},
+		"Lead":     {"lead"},
+		"Untagged": nil,
+	}
+	for name, tags := range contacts {
+		contact, err := db.CreateContact(ctx, database.CreateContactParams{OrganizationID: org.ID, Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tagName := range tags {
+			tag, err := db.UpsertTag(ctx, database.UpsertTagParams{OrganizationID: org.ID, Name: tagName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.AddTagToContact(ctx, database.AddTagToContactParams{ContactID: contact.ID, TagID: tag.ID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		matchAll bool
+		want     []string
+	}{
+		{"no filter", []string{}, false, []string{"Both", "Lead", "Untagged"}},
+		{"nil filter", nil, false, []string{"Both", "Lead", "Untagged"}},
+		{"any tag", []string{"lead", "customer"}, false, []string{"Both", "Lead"}},
+		{"all tags", []string{"lead", "customer"}, true, []string{"Both"}},
+		{"unknown tag", []string{"partner"}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := db.GetContactsPaginated(ctx, database.GetContactsPaginatedParams{
+				OrganizationID: org.ID,
+				Tags:           tt.tags,
+				MatchAllTags:   tt.matchAll,
+				Limit:          10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, c := range rows {
+				got = append(got, c.Name)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,37 +6,299 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         int64
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
+type AppSetting struct {
+	ID               bool
+	RequireTwoFactor bool
+	UpdatedAt        time.Time
+}
+
+type Company struct {
+	ID             int64
+	OrganizationID uuid.UUID
+	Name           string
+	Domain         sql.NullString
+	Industry       sql.NullString
+	Size           sql.NullString
+	Address        sql.NullString
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
 type Contact struct {
-	ID        int64
-	UserID    uuid.UUID
+	ID                int64
+	OwnerID           uuid.NullUUID
+	Name              string
+	Email             sql.NullString
+	Phone             sql.NullString
+	Company           sql.NullString
+	Position          sql.NullString
+	Notes             sql.NullString
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	CompanyID         sql.NullInt64
+	DeletedAt         sql.NullTime
+	OrganizationID    uuid.UUID
+	CreatedBy         uuid.NullUUID
+	CompanyAutoLinked bool
+}
+
+type ContactActivity struct {
+	ID             int64
+	OrganizationID uuid.UUID
+	ContactID      int64
+	ActorID        uuid.NullUUID
+	Kind           string
+	Summary        string
+	Details        json.RawMessage
+	CreatedAt      time.Time
+}
+
+type ContactCustomValue struct {
+	ContactID int64
+	FieldID   int64
+	Value     string
+	UpdatedAt time.Time
+}
+
+type ContactTag struct {
+	ContactID int64
+	TagID     int64
+	CreatedAt time.Time
+}
+
+type CustomField struct {
+	ID             int64
+	OrganizationID uuid.UUID
+	Name           string
+	Key            string
+	FieldType      string
+	Options        []string
+	Required       bool
+	Position       int32
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type Deal struct {
+	ID             int64
+	UserID         uuid.UUID
+	PipelineID     int64
+	StageID        int64
+	Title          string
+	AmountCents    int64
+	Currency       string
+	CloseDate      sql.NullTime
+	OwnerID        uuid.NullUUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uuid.UUID
+}
+
+type DealContact struct {
+	DealID    int64
+	ContactID int64
+	CreatedAt time.Time
+}
+
+type DealStageHistory struct {
+	ID          int64
+	DealID      int64
+	FromStageID sql.NullInt64
+	ToStageID   sql.NullInt64
+	ChangedBy   uuid.NullUUID
+	ChangedAt   time.Time
+}
+
+type EmailOutbox struct {
+	ID            int64
+	FromEmail     string
+	FromName      string
+	ToEmail       string
+	Subject       string
+	BodyText      string
+	Category      string
+	Status        string
+	Attempts      int32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	SentAt        sql.NullTime
+	BodyHtml      string
+}
+
+type LoginThrottle struct {
+	Key             string
+	Failures        int32
+	LastFailureAt   time.Time
+	LockedUntil     sql.NullTime
+	UnlockTokenHash sql.NullString
+}
+
+type Organization struct {
+	ID        uuid.UUID
 	Name      string
-	Email     sql.NullString
-	Phone     sql.NullString
-	Company   sql.NullString
-	Position  sql.NullString
-	Notes     sql.NullString
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-type User struct {
+type OrganizationInvitation struct {
+	ID             int64
+	OrganizationID uuid.UUID
+	Email          string
+	Role           string
+	InvitedBy      uuid.NullUUID
+	ExpiresAt      time.Time
+	AcceptedAt     sql.NullTime
+	AcceptedBy     uuid.NullUUID
+	RevokedAt      sql.NullTime
+	CreatedAt      time.Time
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+	CreatedAt      time.Time
+}
+
+type PasswordResetToken struct {
+	ID        int64
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type Pipeline struct {
+	ID             int64
+	UserID         uuid.UUID
+	Name           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uuid.UUID
+}
+
+type PipelineStage struct {
+	ID         int64
+	PipelineID int64
+	Name       string
+	Position   int32
+	CreatedAt  time.Time
+}
+
+type RecoveryCode struct {
+	ID        int64
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type Session struct {
 	ID                uuid.UUID
-	Username          string
-	Email             string
-	FirstName         string
-	LastName          string
-	PasswordHash      string
-	EmailVerified     bool
-	Role              string
-	Plan              string
-	VerificationToken sql.NullString
-	TokenSentAt       sql.NullTime
-	StripeCustomerID  sql.NullString
+	UserID            uuid.UUID
+	RefreshTokenHash  string
+	PreviousTokenHash sql.NullString
+	RotatedAt         sql.NullTime
+	UserAgent         string
+	IpAddress         string
+	CreatedAt         time.Time
+	LastUsedAt        time.Time
+	ExpiresAt         time.Time
+	RevokedAt         sql.NullTime
+}
+
+type StripeEvent struct {
+	ID              string
+	Type            string
+	Payload         json.RawMessage
+	StripeCreatedAt time.Time
+	Status          string
+	Attempts        int32
+	LastError       sql.NullString
+	NextAttemptAt   time.Time
+	ReceivedAt      time.Time
+	ProcessedAt     sql.NullTime
+}
+
+type Subscription struct {
+	ID                string
+	UserID            uuid.UUID
+	StripeCustomerID  string
+	Status            string
+	PriceID           sql.NullString
+	CurrentPeriodEnd  sql.NullTime
+	CancelAtPeriodEnd bool
+	TrialEnd          sql.NullTime
+	CanceledAt        sql.NullTime
+	StripeEventAt     time.Time
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
 }
+
+type Tag struct {
+	ID             int64
+	OrganizationID uuid.UUID
+	Name           string
+	CreatedAt      time.Time
+}
+
+type Task struct {
+	ID             int64
+	UserID         uuid.UUID
+	ContactID      int64
+	AssigneeID     uuid.NullUUID
+	Title          string
+	Description    sql.NullString
+	DueAt          sql.NullTime
+	Priority       string
+	Status         string
+	RemindedAt     sql.NullTime
+	CompletedAt    sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uuid.UUID
+}
+
+type User struct {
+	ID                    uuid.UUID
+	Username              string
+	Email                 string
+	FirstName             string
+	LastName              string
+	PasswordHash          string
+	EmailVerified         bool
+	Role                  string
+	Plan                  string
+	VerificationToken     sql.NullString
+	TokenSentAt           sql.NullTime
+	StripeCustomerID      sql.NullString
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	PasswordChangedAt     sql.NullTime
+	TotpSecret            sql.NullString
+	TotpEnabled           bool
+	TotpLastStep          sql.NullInt64
+	CurrentOrganizationID uuid.NullUUID
+	TrialEndsAt           sql.NullTime
+	TrialRemindedAt       sql.NullTime
+}
@@ -0,0 +1,49 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/dbtest"
+)
+
+func TestSyncUserPlanKeepsUntrackedPro(t *testing.T) {
+	ctx := context.Background()
+	db := database.New(dbtest.Open(t))
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{
+		Username: "ada", Email: "ada@example.com", FirstName: "Ada", PasswordHash: "x", Role: "user", Plan: "pro",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := db.SyncUserPlan(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan != "pro" {
+		t.Errorf("without a subscription row: got plan %q, want pro", plan)
+	}
+
+	_, err = db.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+		ID:               "sub_123",
+		UserID:           user.ID,
+		StripeCustomerID: "cus_123",
+		Status:           "canceled",
+		StripeEventAt:    time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err = db.SyncUserPlan(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan != "free" {
+		t.Errorf("with a canceled subscription: got plan %q, want free", plan)
+	}
+}
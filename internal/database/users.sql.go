@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -25,7 +26,7 @@ INSERT INTO users (
     token_sent_at
 )
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at
+RETURNING id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at
 `
 
 type CreateUserParams struct {
@@ -68,6 +69,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.CurrentOrganizationID,
+		&i.TrialEndsAt,
+		&i.TrialRemindedAt,
 	)
 	return i, err
 }
@@ -83,17 +91,164 @@ func (q *Queries) DeleteExpiredUnverifiedUsers(ctx context.Context) error {
 	return err
 }
 
-const downgradeUserPlanByStripeCustomerID = `-- name: DowngradeUserPlanByStripeCustomerID :exec
-UPDATE users SET plan = 'free' WHERE stripe_customer_id = $1
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled = false,
+    totp_last_step = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, id)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled = true,
+    updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL
 `
 
-func (q *Queries) DowngradeUserPlanByStripeCustomerID(ctx context.Context, stripeCustomerID sql.NullString) error {
-	_, err := q.db.ExecContext(ctx, downgradeUserPlanByStripeCustomerID, stripeCustomerID)
+func (q *Queries) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableTOTP, id)
 	return err
 }
 
+const expireTrial = `-- name: ExpireTrial :execrows
+UPDATE users
+SET plan = 'free',
+    updated_at = NOW()
+WHERE id = $1
+  AND plan = 'trial'
+  AND trial_ends_at <= NOW()
+`
+
+func (q *Queries) ExpireTrial(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireTrial, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDueTrialReminders = `-- name: GetDueTrialReminders :many
+SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at FROM users
+WHERE plan = 'trial'
+  AND trial_reminded_at IS NULL
+  AND trial_ends_at > NOW()
+  AND trial_ends_at <= $1
+ORDER BY trial_ends_at
+LIMIT $2
+`
+
+type GetDueTrialRemindersParams struct {
+	RemindBefore sql.NullTime
+	BatchSize    int32
+}
+
+func (q *Queries) GetDueTrialReminders(ctx context.Context, arg GetDueTrialRemindersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getDueTrialReminders, arg.RemindBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.FirstName,
+			&i.LastName,
+			&i.PasswordHash,
+			&i.EmailVerified,
+			&i.Role,
+			&i.Plan,
+			&i.VerificationToken,
+			&i.TokenSentAt,
+			&i.StripeCustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PasswordChangedAt,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.TotpLastStep,
+			&i.CurrentOrganizationID,
+			&i.TrialEndsAt,
+			&i.TrialRemindedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredTrials = `-- name: GetExpiredTrials :many
+SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at FROM users
+WHERE plan = 'trial'
+  AND trial_ends_at <= NOW()
+ORDER BY trial_ends_at
+LIMIT $1
+`
+
+func (q *Queries) GetExpiredTrials(ctx context.Context, limit int32) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredTrials, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.FirstName,
+			&i.LastName,
+			&i.PasswordHash,
+			&i.EmailVerified,
+			&i.Role,
+			&i.Plan,
+			&i.VerificationToken,
+			&i.TokenSentAt,
+			&i.StripeCustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PasswordChangedAt,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.TotpLastStep,
+			&i.CurrentOrganizationID,
+			&i.TrialEndsAt,
+			&i.TrialRemindedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at FROM users
+SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at FROM users
 WHERE email = $1
 LIMIT 1
 `
@@ -116,12 +271,19 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.CurrentOrganizationID,
+		&i.TrialEndsAt,
+		&i.TrialRemindedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at FROM users WHERE id = $1
+SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -142,12 +304,54 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.CurrentOrganizationID,
+		&i.TrialEndsAt,
+		&i.TrialRemindedAt,
+	)
+	return i, err
+}
+
+const getUserByStripeCustomerID = `-- name: GetUserByStripeCustomerID :one
+SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at FROM users
+WHERE stripe_customer_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByStripeCustomerID(ctx context.Context, stripeCustomerID sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByStripeCustomerID, stripeCustomerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.FirstName,
+		&i.LastName,
+		&i.PasswordHash,
+		&i.EmailVerified,
+		&i.Role,
+		&i.Plan,
+		&i.VerificationToken,
+		&i.TokenSentAt,
+		&i.StripeCustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.CurrentOrganizationID,
+		&i.TrialEndsAt,
+		&i.TrialRemindedAt,
 	)
 	return i, err
 }
 
 const getUserByVerificationToken = `-- name: GetUserByVerificationToken :one
-SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at FROM users
+SELECT id, username, email, first_name, last_name, password_hash, email_verified, role, plan, verification_token, token_sent_at, stripe_customer_id, created_at, updated_at, password_changed_at, totp_secret, totp_enabled, totp_last_step, current_organization_id, trial_ends_at, trial_reminded_at FROM users
 WHERE verification_token = $1
 `
 
@@ -169,33 +373,163 @@ func (q *Queries) GetUserByVerificationToken(ctx context.Context, verificationTo
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordChangedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.CurrentOrganizationID,
+		&i.TrialEndsAt,
+		&i.TrialRemindedAt,
 	)
 	return i, err
 }
 
-const updateStripeCustomerIDByEmail = `-- name: UpdateStripeCustomerIDByEmail :exec
-UPDATE users SET stripe_customer_id = $2 WHERE email = $1
+const markTrialReminded = `-- name: MarkTrialReminded :exec
+UPDATE users
+SET trial_reminded_at = NOW()
+WHERE id = $1
 `
 
-type UpdateStripeCustomerIDByEmailParams struct {
-	Email            string
+func (q *Queries) MarkTrialReminded(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markTrialReminded, id)
+	return err
+}
+
+const resendVerificationToken = `-- name: ResendVerificationToken :execrows
+UPDATE users
+SET verification_token = $1,
+    token_sent_at = NOW(),
+    updated_at = NOW()
+WHERE id = $2
+  AND email_verified = false
+  AND (token_sent_at IS NULL OR token_sent_at < $3)
+`
+
+type ResendVerificationTokenParams struct {
+	VerificationToken sql.NullString
+	ID                uuid.UUID
+	SentBefore        time.Time
+}
+
+func (q *Queries) ResendVerificationToken(ctx context.Context, arg ResendVerificationTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resendVerificationToken, arg.VerificationToken, arg.ID, arg.SentBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCurrentOrganization = `-- name: SetCurrentOrganization :exec
+UPDATE users
+SET current_organization_id = $2
+WHERE id = $1
+`
+
+type SetCurrentOrganizationParams struct {
+	ID                    uuid.UUID
+	CurrentOrganizationID uuid.NullUUID
+}
+
+func (q *Queries) SetCurrentOrganization(ctx context.Context, arg SetCurrentOrganizationParams) error {
+	_, err := q.db.ExecContext(ctx, setCurrentOrganization, arg.ID, arg.CurrentOrganizationID)
+	return err
+}
+
+const setTOTPSecret = `-- name: SetTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2,
+    totp_enabled = false,
+    totp_last_step = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetTOTPSecretParams struct {
+	ID         uuid.UUID
+	TotpSecret sql.NullString
+}
+
+func (q *Queries) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setTOTPSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const startTrial = `-- name: StartTrial :execrows
+UPDATE users
+SET plan = 'trial',
+    trial_ends_at = $2,
+    trial_reminded_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND plan = 'free'
+  AND trial_ends_at IS NULL
+`
+
+type StartTrialParams struct {
+	ID          uuid.UUID
+	TrialEndsAt sql.NullTime
+}
+
+func (q *Queries) StartTrial(ctx context.Context, arg StartTrialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, startTrial, arg.ID, arg.TrialEndsAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateStripeCustomerID = `-- name: UpdateStripeCustomerID :exec
+UPDATE users SET stripe_customer_id = $2 WHERE id = $1
+`
+
+type UpdateStripeCustomerIDParams struct {
+	ID               uuid.UUID
 	StripeCustomerID sql.NullString
 }
 
-func (q *Queries) UpdateStripeCustomerIDByEmail(ctx context.Context, arg UpdateStripeCustomerIDByEmailParams) error {
-	_, err := q.db.ExecContext(ctx, updateStripeCustomerIDByEmail, arg.Email, arg.StripeCustomerID)
+func (q *Queries) UpdateStripeCustomerID(ctx context.Context, arg UpdateStripeCustomerIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateStripeCustomerID, arg.ID, arg.StripeCustomerID)
 	return err
 }
 
-const upgradeUserPlanByEmail = `-- name: UpgradeUserPlanByEmail :exec
-UPDATE users SET plan = 'pro' WHERE email = $1
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2,
+    password_changed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
 `
 
-func (q *Queries) UpgradeUserPlanByEmail(ctx context.Context, email string) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserPlanByEmail, email)
+type UpdateUserPasswordParams struct {
+	ID           uuid.UUID
+	PasswordHash string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
 	return err
 }
 
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1
+  AND (totp_last_step IS NULL OR totp_last_step < $2)
+`
+
+type UseTOTPStepParams struct {
+	ID           uuid.UUID
+	TotpLastStep sql.NullInt64
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const verifyUserEmail = `-- name: VerifyUserEmail :exec
 UPDATE users
 SET email_verified = true,
@@ -0,0 +1,208 @@
+package dedupe
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	ReasonEmail       = "email"
+	ReasonPhone       = "phone"
+	ReasonNameCompany = "name_company"
+
+	// NameSimilarityThreshold is the minimum similarity for two names at the
+	// same company to be reported as duplicates.
+	NameSimilarityThreshold = 0.85
+
+	minPhoneDigits = 7
+)
+
+var companySuffixes = regexp.MustCompile(`\b(inc|incorporated|llc|ltd|limited|corp|corporation|co|company|gmbh|plc|sa|ag)\b`)
+
+// Candidate is the subset of contact data used for matching.
+type Candidate struct {
+	ID      int64
+	Name    string
+	Email   string
+	Phone   string
+	Company string
+}
+
+// Group is a set of contacts considered duplicates for one reason.
+type Group struct {
+	Reason string
+	Key    string
+	IDs    []int64
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone keeps only digits, so "+1 (555) 010-2000" and
+// "15550102000" compare equal.
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeName lowercases, strips punctuation and collapses whitespace.
+func NormalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// NormalizeCompany is NormalizeName with common legal suffixes removed, so
+// "ACME Inc." and "acme" compare equal.
+func NormalizeCompany(company string) string {
+	return strings.Join(strings.Fields(companySuffixes.ReplaceAllString(NormalizeName(company), " ")), " ")
+}
+
+// Similarity returns a score between 0 and 1 based on Levenshtein distance.
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// FindDuplicates groups candidates that share an email address, a phone
+// number, or a near-identical name at the same company.
+func FindDuplicates(candidates []Candidate) []Group {
+	var groups []Group
+
+	groups = append(groups, groupByKey(candidates, ReasonEmail, func(c Candidate) string {
+		return NormalizeEmail(c.Email)
+	})...)
+
+	groups = append(groups, groupByKey(candidates, ReasonPhone, func(c Candidate) string {
+		if p := NormalizePhone(c.Phone); len(p) >= minPhoneDigits {
+			return p
+		}
+		return ""
+	})...)
+
+	// Fuzzy names are only compared within the same company
+	byCompany := map[string][]Candidate{}
+	for _, c := range candidates {
+		if company := NormalizeCompany(c.Company); company != "" {
+			byCompany[company] = append(byCompany[company], c)
+		}
+	}
+
+	companies := make([]string, 0, len(byCompany))
+	for company := range byCompany {
+		companies = append(companies, company)
+	}
+	sort.Strings(companies)
+
+	for _, company := range companies {
+		members := byCompany[company]
+		parent := make([]int, len(members))
+		for i := range parent {
+			parent[i] = i
+		}
+		var find func(int) int
+		find = func(i int) int {
+			if parent[i] != i {
+				parent[i] = find(parent[i])
+			}
+			return parent[i]
+		}
+
+		names := make([]string, len(members))
+		for i, m := range members {
+			names[i] = NormalizeName(m.Name)
+		}
+		for i := range members {
+			for j := i + 1; j < len(members); j++ {
+				if Similarity(names[i], names[j]) >= NameSimilarityThreshold {
+					parent[find(j)] = find(i)
+				}
+			}
+		}
+
+		clusters := map[int][]int64{}
+		for i, m := range members {
+			root := find(i)
+			clusters[root] = append(clusters[root], m.ID)
+		}
+		for root, ids := range clusters {
+			if len(ids) < 2 {
+				continue
+			}
+			sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+			groups = append(groups, Group{Reason: ReasonNameCompany, Key: names[root] + " @ " + company, IDs: ids})
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Reason != groups[j].Reason {
+			return groups[i].Reason < groups[j].Reason
+		}
+		return groups[i].IDs[0] < groups[j].IDs[0]
+	})
+	return groups
+}
+
+func groupByKey(candidates []Candidate, reason string, key func(Candidate) string) []Group {
+	byKey := map[string][]int64{}
+	var order []string
+	for _, c := range candidates {
+		k := key(c)
+		if k == "" {
+			continue
+		}
+		if _, seen := byKey[k]; !seen {
+			order = append(order, k)
+		}
+		byKey[k] = append(byKey[k], c.ID)
+	}
+
+	var groups []Group
+	for _, k := range order {
+		if ids := byKey[k]; len(ids) > 1 {
+			groups = append(groups, Group{Reason: reason, Key: k, IDs: ids})
+		}
+	}
+	return groups
+}
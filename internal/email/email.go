@@ -2,91 +2,110 @@ package email
 
 import (
 	"bytes"
-	"encoding/json"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"net/http"
-	"os"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
 )
 
-type MailtrapEmailSender struct {
-	APIKey      string
-	FromName    string
-	FromEmail   string
-	TemplateID  string
-	CompanyInfo map[string]string
+// EmailSender delivers a composed message. Mailtrap, SMTP and a directory of
+// .eml files are available; NewSenderFromEnv picks one from the environment.
+type EmailSender interface {
+	Send(msg Message) error
 }
 
-type MailtrapPayload struct {
-	From struct {
-		Email string `json:"email"`
-		Name  string `json:"name"`
-	} `json:"from"`
-	To []struct {
-		Email string `json:"email"`
-	} `json:"to"`
-	TemplateUUID      string         `json:"template_uuid"`
-	TemplateVariables map[string]any `json:"template_variables"`
+// Message is an email ready to be delivered. HTML is optional; when set it is
+// sent as an alternative to Text.
+type Message struct {
+	FromEmail string
+	FromName  string
+	To        string
+	Subject   string
+	Text      string
+	HTML      string
+	// Category groups messages in providers that support it, e.g. Mailtrap
+	Category string
 }
 
-func NewMailtrapSender() *MailtrapEmailSender {
-	return &MailtrapEmailSender{
-		APIKey:     os.Getenv("MAILTRAP_API_KEY"),
-		FromEmail:  os.Getenv("MAILTRAP_FROM_EMAIL"),
-		FromName:   os.Getenv("MAILTRAP_FROM_NAME"),
-		TemplateID: os.Getenv("MAILTRAP_TEMPLATE_ID"),
-		CompanyInfo: map[string]string{
-			"company_info_name":     os.Getenv("MAILTRAP_COMPANY_NAME"),
-			"company_info_address":  os.Getenv("MAILTRAP_COMPANY_ADDRESS"),
-			"company_info_city":     os.Getenv("MAILTRAP_COMPANY_CITY"),
-			"company_info_zip_code": os.Getenv("MAILTRAP_COMPANY_ZIP"),
-			"company_info_country":  os.Getenv("MAILTRAP_COMPANY_COUNTRY"),
-		},
+// Bytes formats the message as an RFC 5322 email, as sent over SMTP and
+// written to .eml files.
+func (m Message) Bytes() ([]byte, error) {
+	id, err := messageID(m.FromEmail)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (m *MailtrapEmailSender) SendVerificationEmail(toEmail, name, verifyLink string) error {
-	payload := MailtrapPayload{}
-	payload.From.Email = m.FromEmail
-	payload.From.Name = m.FromName
-	payload.To = []struct {
-		Email string `json:"email"`
-	}{{Email: toEmail}}
-	payload.TemplateUUID = m.TemplateID
+	from := mail.Address{Name: m.FromName, Address: m.FromEmail}
+	to := mail.Address{Address: m.To}
 
-	payload.TemplateVariables = map[string]any{
-		"name":                    name,
-		"email_verification_link": verifyLink,
-	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", id)
+	buf.WriteString("MIME-Version: 1.0\r\n")
 
-	// Add company info
-	for k, v := range m.CompanyInfo {
-		payload.TemplateVariables[k] = v
+	if m.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
 	}
 
-	bodyBytes, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n", mw.Boundary())
+	buf.WriteString("\r\n")
 
-	req, err := http.NewRequest("POST", "https://send.api.mailtrap.io/api/send", bytes.NewReader(bodyBytes))
-	if err != nil {
-		return err
+	// Clients show the last part they understand, so HTML goes after text
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
 	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Api-Token", m.APIKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	return qp.Close()
+}
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("Mailtrap API error: %s", resp.Status)
+func messageID(fromEmail string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return nil
+	domain := "localhost"
+	if _, d, ok := strings.Cut(fromEmail, "@"); ok && d != "" {
+		domain = d
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
 }
@@ -0,0 +1,83 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSenderWritesVerificationEmail(t *testing.T) {
+	dir := t.TempDir()
+	m := &Mailer{
+		Sender:      &FileSender{Dir: dir},
+		FromEmail:   "hello@example.com",
+		FromName:    "Mini HubSpot",
+		TemplateDir: filepath.Join("..", "..", DefaultTemplateDir),
+	}
+
+	link := "https://app.example.com/verify-email?token=0123456789abcdef0123456789abcdef0123456789abcdef"
+	if err := m.SendVerificationEmail("ada@example.com", "Ada", link); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d .eml files, want 1", len(files))
+	}
+
+	header, text := readEML(t, files[0])
+	if got := header.Get("To"); got != "<ada@example.com>" {
+		t.Errorf("To = %q", got)
+	}
+	if header.Get("Subject") == "" {
+		t.Error("missing Subject")
+	}
+	if !strings.Contains(text, link) {
+		t.Errorf("text part doesn't contain the verification link:\n%s", text)
+	}
+}
+
+// readEML parses a written email and returns its headers and decoded text
+// part.
+func readEML(t *testing.T, path string) (mail.Header, string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parts come back with their quoted-printable encoding undone
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("no text part: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			body, err := io.ReadAll(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return msg.Header, string(body)
+		}
+	}
+}
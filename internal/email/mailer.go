@@ -0,0 +1,182 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Mailer renders the app's emails from templates and hands them to an
+// EmailSender.
+type Mailer struct {
+	Sender    EmailSender
+	FromEmail string
+	FromName  string
+	Company   Company
+	// TemplateDir defaults to DefaultTemplateDir
+	TemplateDir string
+}
+
+// NewMailerFromEnv builds a Mailer around the sender configured by
+// EMAIL_BACKEND, sending from EMAIL_FROM and EMAIL_FROM_NAME.
+func NewMailerFromEnv() (*Mailer, error) {
+	sender, err := NewSenderFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &Mailer{
+		Sender:    sender,
+		FromEmail: os.Getenv("EMAIL_FROM"),
+		FromName:  os.Getenv("EMAIL_FROM_NAME"),
+		Company:   CompanyFromEnv(),
+	}, nil
+}
+
+// NewSenderFromEnv returns the backend named by EMAIL_BACKEND: "mailtrap"
+// (the default), "smtp" or "file".
+func NewSenderFromEnv() (EmailSender, error) {
+	switch backend := os.Getenv("EMAIL_BACKEND"); backend {
+	case "", "mailtrap":
+		return &MailtrapSender{APIKey: os.Getenv("MAILTRAP_API_KEY")}, nil
+	case "smtp":
+		return &SMTPSender{
+			Host:     getEnv("SMTP_HOST", "localhost"),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}, nil
+	case "file":
+		return &FileSender{Dir: getEnv("EMAIL_DIR", "tmp/emails")}, nil
+	default:
+		return nil, fmt.Errorf("unknown EMAIL_BACKEND %q", backend)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Compose renders the named template into a message to toEmail.
+func (m *Mailer) Compose(toEmail, category, name string, data map[string]any) (Message, error) {
+	subject, text, html, err := m.render(name, data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{
+		FromEmail: m.FromEmail,
+		FromName:  m.FromName,
+		To:        toEmail,
+		Subject:   subject,
+		Text:      text,
+		HTML:      html,
+		Category:  category,
+	}, nil
+}
+
+func (m *Mailer) send(toEmail, category, name string, data map[string]any) error {
+	msg, err := m.Compose(toEmail, category, name, data)
+	if err != nil {
+		return err
+	}
+	return m.Sender.Send(msg)
+}
+
+func (m *Mailer) SendVerificationEmail(toEmail, name, verifyLink string) error {
+	return m.send(toEmail, "Email Verification", "verification", map[string]any{
+		"Name": name,
+		"Link": verifyLink,
+	})
+}
+
+// SendTaskReminderEmail tells the assignee that a task has become due.
+func (m *Mailer) SendTaskReminderEmail(toEmail, name, taskTitle, contactName, taskLink string) error {
+	return m.send(toEmail, "Task Reminder", "task_reminder", map[string]any{
+		"Name":        name,
+		"TaskTitle":   taskTitle,
+		"ContactName": contactName,
+		"Link":        taskLink,
+	})
+}
+
+// SendPasswordResetEmail sends the link for choosing a new password.
+func (m *Mailer) SendPasswordResetEmail(toEmail, name, resetLink string, expiresIn time.Duration) error {
+	return m.send(toEmail, "Password Reset", "password_reset", map[string]any{
+		"Name":             name,
+		"Link":             resetLink,
+		"ExpiresInMinutes": int(expiresIn.Minutes()),
+	})
+}
+
+// SendAccountLockedEmail warns that logins were locked after repeated failed
+// attempts and links to unlock the account early.
+func (m *Mailer) SendAccountLockedEmail(toEmail, name, unlockLink string, lockedFor time.Duration) error {
+	return m.send(toEmail, "Account Locked", "account_locked", map[string]any{
+		"Name":             name,
+		"Link":             unlockLink,
+		"LockedForMinutes": int(lockedFor.Minutes()),
+	})
+}
+
+// SendInvitationEmail invites someone to join an organization.
+func (m *Mailer) SendInvitationEmail(toEmail, inviterName, organizationName, role, inviteLink string, expiresIn time.Duration) error {
+	return m.send(toEmail, "Invitation", "invitation", map[string]any{
+		"InviterName":      inviterName,
+		"OrganizationName": organizationName,
+		"Role":             role,
+		"Link":             inviteLink,
+		"ExpiresInDays":    int(expiresIn.Hours() / 24),
+	})
+}
+
+// SendPaymentFailedEmail asks the customer to update their card after Stripe
+// could not collect a payment.
+func (m *Mailer) SendPaymentFailedEmail(toEmail, name, billingLink string) error {
+	return m.send(toEmail, "Billing", "payment_failed", map[string]any{
+		"Name": name,
+		"Link": billingLink,
+	})
+}
+
+// SendTrialEndingEmail reminds the customer that their trial is about to turn
+// into a paid subscription.
+func (m *Mailer) SendTrialEndingEmail(toEmail, name, plansLink string, trialEnd time.Time) error {
+	return m.send(toEmail, "Billing", "trial_ending", map[string]any{
+		"Name":        name,
+		"Link":        plansLink,
+		"TrialEndsOn": trialEnd.Format("January 2, 2006"),
+	})
+}
+
+// SendTrialReminderEmail warns that a Pro trial is about to end and what the
+// Free plan keeps. A contactLimit of zero means Free has no contacts limit.
+func (m *Mailer) SendTrialReminderEmail(toEmail, name, plansLink string, trialEnd time.Time, contactLimit int) error {
+	return m.send(toEmail, "Billing", "trial_reminder", map[string]any{
+		"Name":         name,
+		"Link":         plansLink,
+		"TrialEndsOn":  trialEnd.Format("January 2, 2006"),
+		"ContactLimit": contactLimit,
+	})
+}
+
+// SendTrialExpiredEmail confirms that a Pro trial has ended. Users owning more
+// contacts than the Free plan allows are told what that means.
+func (m *Mailer) SendTrialExpiredEmail(toEmail, name, plansLink string, contacts int64, contactLimit int) error {
+	return m.send(toEmail, "Billing", "trial_expired", map[string]any{
+		"Name":         name,
+		"Link":         plansLink,
+		"Contacts":     contacts,
+		"ContactLimit": contactLimit,
+	})
+}
+
+// SendSubscriptionCanceledEmail confirms that the account is back on the free
+// plan.
+func (m *Mailer) SendSubscriptionCanceledEmail(toEmail, name, plansLink string) error {
+	return m.send(toEmail, "Billing", "subscription_canceled", map[string]any{
+		"Name": name,
+		"Link": plansLink,
+	})
+}
@@ -0,0 +1,69 @@
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const mailtrapSendURL = "https://send.api.mailtrap.io/api/send"
+
+// MailtrapSender sends through the Mailtrap email API.
+type MailtrapSender struct {
+	APIKey string
+}
+
+type MailtrapPayload struct {
+	From struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	} `json:"from"`
+	To []struct {
+		Email string `json:"email"`
+	} `json:"to"`
+	Subject  string `json:"subject,omitempty"`
+	Text     string `json:"text,omitempty"`
+	HTML     string `json:"html,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+func (m *MailtrapSender) Send(msg Message) error {
+	payload := MailtrapPayload{}
+	payload.From.Email = msg.FromEmail
+	payload.From.Name = msg.FromName
+	payload.To = []struct {
+		Email string `json:"email"`
+	}{{Email: msg.To}}
+	payload.Subject = msg.Subject
+	payload.Text = msg.Text
+	payload.HTML = msg.HTML
+	payload.Category = msg.Category
+
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", mailtrapSendURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Api-Token", m.APIKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("Mailtrap API error: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+)
+
+// Outbox statuses
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+const (
+	// MaxDeliveryAttempts is how often delivery is tried before a message is
+	// dead-lettered for an admin to look at.
+	MaxDeliveryAttempts = 8
+
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = 6 * time.Hour
+
+	// deliveryLease keeps other workers away from a claimed message. It only
+	// matters if a worker dies mid-send, after which the message is retried.
+	deliveryLease = 5 * time.Minute
+)
+
+// OutboxSender queues messages in the email_outbox table. The worker delivers
+// them with Deliver.
+type OutboxSender struct {
+	ctx context.Context
+	db  *database.Queries
+}
+
+func (o *OutboxSender) Send(msg Message) error {
+	return o.db.EnqueueEmail(o.ctx, database.EnqueueEmailParams{
+		FromEmail: msg.FromEmail,
+		FromName:  msg.FromName,
+		ToEmail:   msg.To,
+		Subject:   msg.Subject,
+		BodyText:  msg.Text,
+		BodyHtml:  msg.HTML,
+		Category:  msg.Category,
+	})
+}
+
+// Outbox returns a Mailer that queues emails with db instead of sending them.
+// Pass queries bound to a transaction to queue emails together with the
+// change that triggers them.
+func (m *Mailer) Outbox(ctx context.Context, db *database.Queries) *Mailer {
+	outbox := *m
+	outbox.Sender = &OutboxSender{ctx: ctx, db: db}
+	return &outbox
+}
+
+// Deliver sends up to batchSize due messages from the outbox. Failed messages
+// are retried with exponential backoff and dead-lettered after
+// MaxDeliveryAttempts.
+func Deliver(ctx context.Context, db *database.Queries, sender EmailSender, batchSize int32) (sent, failed int, err error) {
+	emails, err := db.ClaimDueEmails(ctx, database.ClaimDueEmailsParams{
+		LeaseUntil: time.Now().Add(deliveryLease),
+		BatchSize:  batchSize,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, e := range emails {
+		sendErr := sender.Send(Message{
+			FromEmail: e.FromEmail,
+			FromName:  e.FromName,
+			To:        e.ToEmail,
+			Subject:   e.Subject,
+			Text:      e.BodyText,
+			HTML:      e.BodyHtml,
+			Category:  e.Category,
+		})
+		if sendErr == nil {
+			sent++
+			if err := db.MarkEmailSent(ctx, e.ID); err != nil {
+				log.Printf("Error marking email %d as sent: %v", e.ID, err)
+			}
+			continue
+		}
+
+		failed++
+		lastError := sql.NullString{String: sendErr.Error(), Valid: true}
+		if e.Attempts+1 >= MaxDeliveryAttempts {
+			log.Printf("Giving up on email %d to %s after %d attempts: %v", e.ID, e.ToEmail, e.Attempts+1, sendErr)
+			err = db.DeadLetterEmail(ctx, database.DeadLetterEmailParams{ID: e.ID, LastError: lastError})
+		} else {
+			err = db.RetryEmailLater(ctx, database.RetryEmailLaterParams{
+				ID:            e.ID,
+				LastError:     lastError,
+				NextAttemptAt: time.Now().Add(retryDelay(e.Attempts + 1)),
+			})
+		}
+		if err != nil {
+			log.Printf("Error recording failure of email %d: %v", e.ID, err)
+		}
+	}
+	return sent, failed, nil
+}
+
+// retryDelay doubles the wait after every failed attempt, up to retryMaxDelay.
+func retryDelay(attempts int32) time.Duration {
+	d := retryBaseDelay << (attempts - 1)
+	if d <= 0 || d > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return d
+}
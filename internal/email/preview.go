@@ -0,0 +1,71 @@
+package email
+
+// previewData is sample data for every template, used by the dev preview.
+var previewData = map[string]map[string]any{
+	"verification": {
+		"Name": "Ada",
+		"Link": "http://localhost:8080/verify-email?token=preview",
+	},
+	"password_reset": {
+		"Name":             "Ada",
+		"Link":             "http://localhost:8080/reset-password?token=preview",
+		"ExpiresInMinutes": 60,
+	},
+	"task_reminder": {
+		"Name":        "Ada",
+		"TaskTitle":   "Send the proposal",
+		"ContactName": "Grace Hopper",
+		"Link":        "http://localhost:8080/contacts/1",
+	},
+	"account_locked": {
+		"Name":             "Ada",
+		"Link":             "http://localhost:8080/unlock-account?token=preview",
+		"LockedForMinutes": 60,
+	},
+	"invitation": {
+		"InviterName":      "Grace",
+		"OrganizationName": "Acme",
+		"Role":             "user",
+		"Link":             "http://localhost:8080/invitations/accept?token=preview",
+		"ExpiresInDays":    7,
+	},
+	"payment_failed": {
+		"Name": "Ada",
+		"Link": "http://localhost:8080/plans",
+	},
+	"trial_ending": {
+		"Name":        "Ada",
+		"Link":        "http://localhost:8080/plans",
+		"TrialEndsOn": "March 3, 2025",
+	},
+	"trial_reminder": {
+		"Name":         "Ada",
+		"Link":         "http://localhost:8080/plans",
+		"TrialEndsOn":  "March 3, 2025",
+		"ContactLimit": 100,
+	},
+	"trial_expired": {
+		"Name":         "Ada",
+		"Link":         "http://localhost:8080/plans",
+		"Contacts":     int64(140),
+		"ContactLimit": 100,
+	},
+	"subscription_canceled": {
+		"Name": "Ada",
+		"Link": "http://localhost:8080/plans",
+	},
+}
+
+// Preview renders the named template with sample data.
+func (m *Mailer) Preview(name string) (Message, error) {
+	sample, ok := previewData[name]
+	if !ok {
+		return Message{}, ErrUnknownTemplate
+	}
+
+	data := make(map[string]any, len(sample))
+	for k, v := range sample {
+		data[k] = v
+	}
+	return m.Compose("preview@example.com", "Preview", name, data)
+}
@@ -0,0 +1,30 @@
+package email
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SMTPSender sends through a plain SMTP server. STARTTLS is used when the
+// server offers it. Without a username no authentication is attempted, which
+// suits local stand-ins such as MailHog or Mailpit.
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, msg.FromEmail, []string{msg.To}, body)
+}
@@ -0,0 +1,95 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultTemplateDir holds a <name>.txt and <name>.html template for every
+// email, wrapped by layout.txt and layout.html.
+const DefaultTemplateDir = "frontend/templates/emails"
+
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// Company is the sender's postal details, shown in every email's footer.
+type Company struct {
+	Name    string
+	Address string
+	City    string
+	ZipCode string
+	Country string
+}
+
+// CompanyFromEnv reads COMPANY_*, falling back to the MAILTRAP_COMPANY_*
+// variables that used to fill the Mailtrap template.
+func CompanyFromEnv() Company {
+	env := func(key string) string {
+		if v := os.Getenv("COMPANY_" + key); v != "" {
+			return v
+		}
+		return os.Getenv("MAILTRAP_COMPANY_" + key)
+	}
+	return Company{
+		Name:    env("NAME"),
+		Address: env("ADDRESS"),
+		City:    env("CITY"),
+		ZipCode: env("ZIP"),
+		Country: env("COUNTRY"),
+	}
+}
+
+// render executes the named email's templates. The text template defines
+// the subject as well as the body. Templates are read on every call, like
+// the page templates, so edits show up without a restart.
+func (m *Mailer) render(name string, data map[string]any) (subject, text, html string, err error) {
+	dir := m.TemplateDir
+	if dir == "" {
+		dir = DefaultTemplateDir
+	}
+	if strings.ContainsAny(name, `/\.`) {
+		return "", "", "", ErrUnknownTemplate
+	}
+	if _, err := os.Stat(filepath.Join(dir, name+".txt")); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", "", ErrUnknownTemplate
+		}
+		return "", "", "", err
+	}
+
+	data["Company"] = m.Company
+	data["AppName"] = m.FromName
+
+	textTmpl, err := texttemplate.ParseFiles(filepath.Join(dir, "layout.txt"), filepath.Join(dir, name+".txt"))
+	if err != nil {
+		return "", "", "", err
+	}
+	var buf bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+	data["Subject"] = subject
+
+	buf.Reset()
+	if err := textTmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+
+	htmlTmpl, err := htmltemplate.ParseFiles(filepath.Join(dir, "layout.html"), filepath.Join(dir, name+".html"))
+	if err != nil {
+		return "", "", "", err
+	}
+	buf.Reset()
+	if err := htmlTmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
@@ -0,0 +1,272 @@
+// Package entitlements describes what each plan includes, as numeric limits
+// and feature flags loaded from a JSON config file.
+package entitlements
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+)
+
+// DefaultConfigPath is read when PLANS_CONFIG is not set.
+const DefaultConfigPath = "config/plans.json"
+
+// Limits and features checked in code. The config has to declare them all.
+const (
+	LimitContacts = "contacts"
+
+	FeatureCSVExport    = "csv_export"
+	FeaturePipelines    = "pipelines"
+	FeatureEmailSupport = "email_support"
+)
+
+var ErrLimitReached = errors.New("plan limit reached")
+
+// LimitError reports which limit a plan has run into.
+type LimitError struct {
+	Limit string
+	Label string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d reached", e.Limit, e.Max)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitReached
+}
+
+// Message explains the limit to the user.
+func (e *LimitError) Message() string {
+	return fmt.Sprintf("Your plan includes up to %d %s. Upgrade to add more.", e.Max, strings.ToLower(e.Label))
+}
+
+// Entry declares a limit or feature and how the plans page labels it.
+type Entry struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// Plan is what one plan includes. A limit missing from Limits is unlimited.
+// Public plans are listed on the plans page.
+type Plan struct {
+	Name     string         `json:"name"`
+	Label    string         `json:"label"`
+	Public   bool           `json:"public"`
+	Limits   map[string]int `json:"limits"`
+	Features []string       `json:"features"`
+}
+
+// Registry answers what a plan includes.
+type Registry struct {
+	Limits   []Entry `json:"limits"`
+	Features []Entry `json:"features"`
+	Plans    []Plan  `json:"plans"`
+}
+
+// LoadFromEnv loads the file in PLANS_CONFIG, or DefaultConfigPath.
+func LoadFromEnv() (*Registry, error) {
+	path := os.Getenv("PLANS_CONFIG")
+	if path == "" {
+		path = DefaultConfigPath
+	}
+	return Load(path)
+}
+
+// Load reads and checks a plans config file.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return reg, nil
+}
+
+// Parse decodes a plans config. Every plan a user can be on, and every limit
+// and feature the code checks, must be declared.
+func Parse(data []byte) (*Registry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var reg Registry
+	if err := dec.Decode(&reg); err != nil {
+		return nil, err
+	}
+	if err := reg.validate(); err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
+
+func (r *Registry) validate() error {
+	limits, err := entryKeys("limit", r.Limits)
+	if err != nil {
+		return err
+	}
+	features, err := entryKeys("feature", r.Features)
+	if err != nil {
+		return err
+	}
+
+	plans := make(map[string]bool, len(r.Plans))
+	for _, p := range r.Plans {
+		if p.Name == "" {
+			return errors.New("plan without a name")
+		}
+		if plans[p.Name] {
+			return fmt.Errorf("plan %q is declared twice", p.Name)
+		}
+		plans[p.Name] = true
+
+		for key, n := range p.Limits {
+			if !limits[key] {
+				return fmt.Errorf("plan %q sets undeclared limit %q", p.Name, key)
+			}
+			if n < 0 {
+				return fmt.Errorf("plan %q sets a negative %q limit", p.Name, key)
+			}
+		}
+		for _, key := range p.Features {
+			if !features[key] {
+				return fmt.Errorf("plan %q includes undeclared feature %q", p.Name, key)
+			}
+		}
+	}
+
+	for _, name := range []string{auth.PlanFree, auth.PlanPro, auth.PlanTrial} {
+		if !plans[name] {
+			return fmt.Errorf("plan %q is not declared", name)
+		}
+	}
+	if !limits[LimitContacts] {
+		return fmt.Errorf("limit %q is not declared", LimitContacts)
+	}
+	for _, key := range []string{FeatureCSVExport, FeaturePipelines, FeatureEmailSupport} {
+		if !features[key] {
+			return fmt.Errorf("feature %q is not declared", key)
+		}
+	}
+	return nil
+}
+
+func entryKeys(kind string, entries []Entry) (map[string]bool, error) {
+	keys := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.Key == "" {
+			return nil, fmt.Errorf("%s without a key", kind)
+		}
+		if keys[e.Key] {
+			return nil, fmt.Errorf("%s %q is declared twice", kind, e.Key)
+		}
+		keys[e.Key] = true
+	}
+	return keys, nil
+}
+
+// Plan looks up a plan by name.
+func (r *Registry) Plan(name string) (Plan, bool) {
+	for _, p := range r.Plans {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Plan{}, false
+}
+
+// HasFeature reports whether a plan includes a feature. Unknown plans include
+// nothing.
+func (r *Registry) HasFeature(plan, feature string) bool {
+	p, ok := r.Plan(plan)
+	return ok && slices.Contains(p.Features, feature)
+}
+
+// Limit returns a plan's limit, and false if it is unlimited. Unknown plans
+// have a limit of zero.
+func (r *Registry) Limit(plan, limit string) (int, bool) {
+	p, ok := r.Plan(plan)
+	if !ok {
+		return 0, true
+	}
+	n, ok := p.Limits[limit]
+	return n, ok
+}
+
+// CheckLimit reports a *LimitError if adding more to what a plan already uses
+// would go over its limit.
+func (r *Registry) CheckLimit(plan, limit string, used, adding int64) error {
+	n, ok := r.Limit(plan, limit)
+	if !ok || used+adding <= int64(n) {
+		return nil
+	}
+	return &LimitError{Limit: limit, Label: r.limitLabel(limit), Max: n}
+}
+
+func (r *Registry) limitLabel(key string) string {
+	for _, e := range r.Limits {
+		if e.Key == key {
+			return e.Label
+		}
+	}
+	return key
+}
+
+// Comparison is the plans page table: a column per public plan and a row per
+// limit and feature.
+type Comparison struct {
+	Plans []Plan
+	Rows  []ComparisonRow
+}
+
+type ComparisonRow struct {
+	Label string
+	Cells []ComparisonCell
+}
+
+// ComparisonCell shows a limit as Text, or a feature as Included.
+type ComparisonCell struct {
+	Text     string
+	Included bool
+}
+
+// Comparison lays out the public plans side by side, in config order.
+func (r *Registry) Comparison() Comparison {
+	var c Comparison
+	for _, p := range r.Plans {
+		if p.Public {
+			c.Plans = append(c.Plans, p)
+		}
+	}
+
+	for _, l := range r.Limits {
+		row := ComparisonRow{Label: l.Label}
+		for _, p := range c.Plans {
+			text := "Unlimited"
+			if n, ok := p.Limits[l.Key]; ok {
+				text = strconv.Itoa(n)
+			}
+			row.Cells = append(row.Cells, ComparisonCell{Text: text})
+		}
+		c.Rows = append(c.Rows, row)
+	}
+	for _, f := range r.Features {
+		row := ComparisonRow{Label: f.Label}
+		for _, p := range c.Plans {
+			row.Cells = append(row.Cells, ComparisonCell{Included: slices.Contains(p.Features, f.Key)})
+		}
+		c.Rows = append(c.Rows, row)
+	}
+	return c
+}
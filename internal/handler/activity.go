@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultActivityPageSize = 20
+	maxActivityPageSize     = 100
+)
+
+func NewActivityResponse(a database.ContactActivity) ActivityResponse {
+	resp := ActivityResponse{
+		ID:        a.ID,
+		Kind:      a.Kind,
+		Summary:   a.Summary,
+		CreatedAt: a.CreatedAt,
+	}
+	// Details is always written by activity.Record, so it is valid JSON
+	json.Unmarshal(a.Details, &resp.Details)
+	if a.ActorID.Valid {
+		resp.ActorID = a.ActorID.UUID.String()
+	}
+	return resp
+}
+
+// LoadActivityPage returns up to limit entries older than the before cursor
+// (0 for the newest), plus the cursor for the next page.
+func LoadActivityPage(ctx context.Context, db *database.Queries, orgID uuid.UUID, contactID, before int64, limit int) ([]ActivityResponse, *int64, error) {
+	if limit <= 0 {
+		limit = defaultActivityPageSize
+	}
+	limit = min(limit, maxActivityPageSize)
+
+	entries, err := db.GetContactActivities(ctx, database.GetContactActivitiesParams{
+		ContactID:      contactID,
+		OrganizationID: orgID,
+		Before:         before,
+		Limit:          int32(limit),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp := make([]ActivityResponse, len(entries))
+	for i, e := range entries {
+		resp[i] = NewActivityResponse(e)
+	}
+
+	var nextCursor *int64
+	if len(entries) == limit {
+		nextCursor = &entries[len(entries)-1].ID
+	}
+	return resp, nextCursor, nil
+}
+
+// recordContactCreated starts the timeline of a new contact. Source tells
+// contacts created by hand apart from imported ones.
+func recordContactCreated(ctx context.Context, db *database.Queries, contact database.Contact, actor uuid.UUID, source string) error {
+	_, err := activity.Record(ctx, db, activity.Entry{
+		OrganizationID: contact.OrganizationID,
+		ContactID:      contact.ID,
+		ActorID:        uuid.NullUUID{UUID: actor, Valid: true},
+		Kind:           activity.KindContactCreated,
+		Summary:        "Contact created",
+		Details:        map[string]any{"source": source},
+	})
+	return err
+}
+
+// customValueChanges lists the custom fields an update actually changes.
+func customValueChanges(defs []database.CustomField, before map[int64]string, after map[int64]*string) []activity.FieldChange {
+	var changes []activity.FieldChange
+	for _, f := range defs {
+		v, ok := after[f.ID]
+		if !ok {
+			continue
+		}
+		to := ""
+		if v != nil {
+			to = *v
+		}
+		if from := before[f.ID]; from != to {
+			changes = append(changes, activity.FieldChange{Field: f.Name, From: from, To: to})
+		}
+	}
+	return changes
+}
+
+func GetContactActivityHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		before, _ := strconv.ParseInt(query.Get("before"), 10, 64)
+
+		entries, nextCursor, err := LoadActivityPage(r.Context(), db, org.ID(), contact.ID, before, limit)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch activity")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"activities":  entries,
+			"next_cursor": nextCursor,
+		})
+	}
+}
+
+// CreateContactActivityHandler logs a note or an email sent outside the app
+// on the contact's timeline.
+func CreateContactActivityHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req CreateActivityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Body = strings.TrimSpace(req.Body)
+		req.Subject = strings.TrimSpace(req.Subject)
+		if req.Body == "" && req.Subject == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Activity body is required")
+			return
+		}
+
+		entry := activity.Entry{
+			OrganizationID: org.ID(),
+			ContactID:      contact.ID,
+			ActorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+			Details:        map[string]any{"body": req.Body},
+		}
+		switch req.Kind {
+		case "", activity.KindNote:
+			entry.Kind = activity.KindNote
+			entry.Summary = "Note added"
+		case activity.KindEmail:
+			entry.Kind = activity.KindEmail
+			entry.Summary = "Email logged"
+			if req.Subject != "" {
+				entry.Summary += ": " + req.Subject
+			}
+			entry.Details["subject"] = req.Subject
+		default:
+			WriteJSONError(w, http.StatusBadRequest, "Activity kind must be note or email")
+			return
+		}
+
+		created, err := activity.Record(r.Context(), db, entry)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not save activity")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(NewActivityResponse(created))
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+)
+
+func GetSettingsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings, err := db.GetAppSettings(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not load settings")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"require_two_factor": settings.RequireTwoFactor,
+		})
+	}
+}
+
+// UpdateSettingsHandler lets admins change app-wide settings. Requiring 2FA
+// sends every user without it to the setup page on their next request.
+func UpdateSettingsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		if req.RequireTwoFactor == nil {
+			WriteJSONError(w, http.StatusBadRequest, "No settings to update")
+			return
+		}
+
+		settings, err := db.UpdateRequireTwoFactor(r.Context(), *req.RequireTwoFactor)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update settings")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"require_two_factor": settings.RequireTwoFactor,
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+)
+
+func NewAPIKeyResponse(k database.ApiKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+	}
+	if k.LastUsedAt.Valid {
+		resp.LastUsedAt = &k.LastUsedAt.Time
+	}
+	return resp
+}
+
+func NewAPIKeyResponseList(keys []database.ApiKey) []APIKeyResponse {
+	resp := make([]APIKeyResponse, len(keys))
+	for i, k := range keys {
+		resp[i] = NewAPIKeyResponse(k)
+	}
+	return resp
+}
+
+func GetAPIKeysHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		keys, err := db.GetAPIKeysByUser(r.Context(), user.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch API keys")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"api_keys": NewAPIKeyResponseList(keys)})
+	}
+}
+
+// CreateAPIKeyHandler returns the full key in the response. Only its hash is
+// stored, so this is the only time it can be seen.
+func CreateAPIKeyHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Key name is required")
+			return
+		}
+
+		if len(req.Scopes) == 0 {
+			WriteJSONError(w, http.StatusBadRequest, "At least one scope is required")
+			return
+		}
+		for _, scope := range req.Scopes {
+			if !slices.Contains(auth.Scopes, scope) {
+				WriteJSONError(w, http.StatusBadRequest, "Unknown scope: "+scope)
+				return
+			}
+		}
+		slices.Sort(req.Scopes)
+		req.Scopes = slices.Compact(req.Scopes)
+
+		key, prefix, err := auth.GenerateAPIKey()
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to generate key")
+			return
+		}
+
+		created, err := db.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+			UserID:  user.ID,
+			Name:    req.Name,
+			Prefix:  prefix,
+			KeyHash: auth.HashToken(key),
+			Scopes:  req.Scopes,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create API key")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"key":     key,
+			"api_key": NewAPIKeyResponse(created),
+		})
+	}
+}
+
+func RevokeAPIKeyHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		keyID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid API key ID")
+			return
+		}
+
+		revoked, err := db.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{
+			ID:     keyID,
+			UserID: user.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not revoke API key")
+			return
+		}
+		if revoked == 0 {
+			WriteJSONError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/billing"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+)
+
+// CheckoutHandler creates a Stripe Checkout Session for the Pro plan and
+// returns its URL for the browser to follow.
+func CheckoutHandler(stripeBilling *billing.Stripe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		if user.Plan == auth.PlanPro {
+			WriteJSONError(w, http.StatusConflict, "You are already on the Pro plan")
+			return
+		}
+
+		host := os.Getenv("APP_HOST")
+		session, err := stripeBilling.CreateCheckoutSession(r.Context(), *user, host+"/plans?checkout=success", host+"/plans")
+		if err != nil {
+			if errors.Is(err, billing.ErrNotConfigured) {
+				WriteJSONError(w, http.StatusServiceUnavailable, "Payments are not set up")
+				return
+			}
+			log.Printf("Failed to create checkout session for user %s: %v", user.ID, err)
+			WriteJSONError(w, http.StatusBadGateway, "Could not start checkout")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CheckoutResponse{URL: session.URL})
+	}
+}
+
+// StartTrialHandler puts the user on a Pro trial. Only free users who
+// haven't had a trial can start one.
+func StartTrialHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		endsAt, err := billing.StartTrial(r.Context(), db, *user)
+		if errors.Is(err, billing.ErrTrialUnavailable) {
+			WriteJSONError(w, http.StatusConflict, "The Pro trial is only available once, on the Free plan")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to start trial for user %s: %v", user.ID, err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not start trial")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TrialResponse{
+			Plan:        auth.PlanTrial,
+			TrialEndsAt: endsAt,
+		})
+	}
+}
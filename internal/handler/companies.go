@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// freeEmailDomains are never matched to a company, since their users do not
+// share an employer.
+var freeEmailDomains = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "hotmail.com", "outlook.com",
+	"live.com", "icloud.com", "me.com", "aol.com", "proton.me", "protonmail.com",
+	"gmx.com", "mail.com", "yandex.com", "zoho.com",
+}
+
+func NewCompanyResponse(c database.Company) CompanyResponse {
+	return CompanyResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		Domain:    c.Domain.String,
+		Industry:  c.Industry.String,
+		Size:      c.Size.String,
+		Address:   c.Address.String,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// normalizeDomain reduces "https://www.Acme.com/about" to "acme.com".
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	domain = strings.TrimPrefix(domain, "www.")
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	return domain
+}
+
+func validDomain(domain string) bool {
+	return strings.Contains(domain, ".") && !strings.ContainsAny(domain, " @")
+}
+
+// emailDomain returns the company domain of an email address, or "" for
+// free webmail providers.
+func emailDomain(email string) string {
+	_, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !ok || domain == "" || slices.Contains(freeEmailDomains, domain) {
+		return ""
+	}
+	return domain
+}
+
+// autoAssociateCompany links a contact to the organization's company whose
+// domain matches the contact's email. A link made this way follows the email
+// when it changes and is dropped if no company matches anymore; links a user
+// made explicitly are never overwritten.
+func autoAssociateCompany(ctx context.Context, db *database.Queries, contact *database.Contact) error {
+	if contact.CompanyID.Valid && !contact.CompanyAutoLinked {
+		return nil
+	}
+
+	var companyID sql.NullInt64
+	if domain := emailDomain(contact.Email.String); domain != "" {
+		company, err := db.GetCompanyByDomain(ctx, database.GetCompanyByDomainParams{
+			OrganizationID: contact.OrganizationID,
+			Domain:         ToNullString(domain),
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			companyID = sql.NullInt64{Int64: company.ID, Valid: true}
+		}
+	}
+
+	if companyID == contact.CompanyID {
+		return nil
+	}
+	contact.CompanyID = companyID
+	contact.CompanyAutoLinked = companyID.Valid
+	return db.SetContactCompany(ctx, database.SetContactCompanyParams{
+		ID:                contact.ID,
+		OrganizationID:    contact.OrganizationID,
+		CompanyID:         contact.CompanyID,
+		CompanyAutoLinked: contact.CompanyAutoLinked,
+	})
+}
+
+// setExplicitCompany applies a company_id sent by the client; 0 unlinks.
+func setExplicitCompany(ctx context.Context, db *database.Queries, contact *database.Contact, companyID int64) error {
+	contact.CompanyID = sql.NullInt64{Int64: companyID, Valid: companyID != 0}
+	contact.CompanyAutoLinked = false
+	return db.SetContactCompany(ctx, database.SetContactCompanyParams{
+		ID:             contact.ID,
+		OrganizationID: contact.OrganizationID,
+		CompanyID:      contact.CompanyID,
+	})
+}
+
+// checkCompanyOwnership writes a JSON error and returns false if companyID is
+// set and not one of the organization's companies.
+func checkCompanyOwnership(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID, companyID *int64) bool {
+	if companyID == nil || *companyID == 0 {
+		return true
+	}
+
+	_, err := db.GetCompanyByID(r.Context(), database.GetCompanyByIDParams{
+		ID:             *companyID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusBadRequest, "Company not found")
+			return false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch company")
+		return false
+	}
+	return true
+}
+
+func companyFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.Company, bool) {
+	companyID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid company ID")
+		return database.Company{}, false
+	}
+
+	company, err := db.GetCompanyByID(r.Context(), database.GetCompanyByIDParams{
+		ID:             companyID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Company not found")
+			return database.Company{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch company")
+		return database.Company{}, false
+	}
+	return company, true
+}
+
+func writeCompanyError(w http.ResponseWriter, err error, fallback string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		WriteJSONError(w, http.StatusBadRequest, "A company with this domain already exists")
+		return
+	}
+	WriteJSONError(w, http.StatusInternalServerError, fallback)
+}
+
+func GetCompaniesHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		companies, err := db.GetCompaniesByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch companies")
+			return
+		}
+
+		resp := make([]CompanyResponse, len(companies))
+		for i, c := range companies {
+			resp[i] = NewCompanyResponse(c)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"companies": resp})
+	}
+}
+
+func CreateCompanyHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req CreateCompanyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Company name is required")
+			return
+		}
+
+		req.Domain = normalizeDomain(req.Domain)
+		if req.Domain != "" && !validDomain(req.Domain) {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid company domain")
+			return
+		}
+
+		company, err := db.CreateCompany(r.Context(), database.CreateCompanyParams{
+			OrganizationID: org.ID(),
+			Name:           req.Name,
+			Domain:         ToNullString(req.Domain),
+			Industry:       ToNullString(strings.TrimSpace(req.Industry)),
+			Size:           ToNullString(strings.TrimSpace(req.Size)),
+			Address:        ToNullString(strings.TrimSpace(req.Address)),
+		})
+		if err != nil {
+			writeCompanyError(w, err, "Could not create company")
+			return
+		}
+
+		resp := NewCompanyResponse(company)
+		if company.Domain.Valid {
+			resp.LinkedContacts, err = db.AssociateContactsByDomain(r.Context(), database.AssociateContactsByDomainParams{
+				CompanyID:      sql.NullInt64{Int64: company.ID, Valid: true},
+				OrganizationID: org.ID(),
+				Domain:         company.Domain.String,
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not link contacts to company")
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func UpdateCompanyHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		existing, ok := companyFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req PatchCompanyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name := existing.Name
+		if req.Name != nil {
+			name = strings.TrimSpace(*req.Name)
+			if name == "" {
+				WriteJSONError(w, http.StatusBadRequest, "Company name is required")
+				return
+			}
+		}
+
+		domain := existing.Domain
+		if req.Domain != nil {
+			d := normalizeDomain(*req.Domain)
+			if d != "" && !validDomain(d) {
+				WriteJSONError(w, http.StatusBadRequest, "Invalid company domain")
+				return
+			}
+			domain = ToNullString(d)
+		}
+
+		choose := func(newVal *string, oldVal sql.NullString) sql.NullString {
+			if newVal != nil {
+				return ToNullString(strings.TrimSpace(*newVal))
+			}
+			return oldVal
+		}
+
+		company, err := db.UpdateCompany(r.Context(), database.UpdateCompanyParams{
+			ID:             existing.ID,
+			OrganizationID: org.ID(),
+			Name:           name,
+			Domain:         domain,
+			Industry:       choose(req.Industry, existing.Industry),
+			Size:           choose(req.Size, existing.Size),
+			Address:        choose(req.Address, existing.Address),
+		})
+		if err != nil {
+			writeCompanyError(w, err, "Could not update company")
+			return
+		}
+
+		resp := NewCompanyResponse(company)
+		if company.Domain.Valid && company.Domain != existing.Domain {
+			resp.LinkedContacts, err = db.AssociateContactsByDomain(r.Context(), database.AssociateContactsByDomainParams{
+				CompanyID:      sql.NullInt64{Int64: company.ID, Valid: true},
+				OrganizationID: org.ID(),
+				Domain:         company.Domain.String,
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not link contacts to company")
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func DeleteCompanyHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		company, ok := companyFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		err := db.DeleteCompany(r.Context(), database.DeleteCompanyParams{
+			ID:             company.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete company")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetCompanyContactsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		company, ok := companyFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		contacts, err := db.GetContactsByCompany(r.Context(), database.GetContactsByCompanyParams{
+			CompanyID:      sql.NullInt64{Int64: company.ID, Valid: true},
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch contacts")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"contacts": NewContactResponseList(contacts)})
+	}
+}
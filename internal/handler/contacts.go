@@ -1,31 +1,54 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
 	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
 	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+	"github.com/google/uuid"
 )
 
 type UpdateContactRequest = CreateContactRequest
 
 func NewContactResponse(c database.Contact) ContactResponse {
+	var companyID *int64
+	if c.CompanyID.Valid {
+		companyID = &c.CompanyID.Int64
+	}
+	var deletedAt *time.Time
+	if c.DeletedAt.Valid {
+		deletedAt = &c.DeletedAt.Time
+	}
+
+	var ownerID string
+	if c.OwnerID.Valid {
+		ownerID = c.OwnerID.UUID.String()
+	}
+
 	return ContactResponse{
-		ID:        c.ID,
-		UserID:    c.UserID,
-		Name:      c.Name,
-		Email:     c.Email.String,
-		Phone:     c.Phone.String,
-		Company:   c.Company.String,
-		Position:  c.Position.String,
-		Notes:     c.Notes.String,
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
+		ID:             c.ID,
+		OwnerID:        ownerID,
+		OrganizationID: c.OrganizationID,
+		Name:           c.Name,
+		Email:          c.Email.String,
+		Phone:          c.Phone.String,
+		Company:        c.Company.String,
+		Position:       c.Position.String,
+		Notes:          c.Notes.String,
+		CompanyID:      companyID,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		DeletedAt:      deletedAt,
 	}
 }
 
@@ -46,59 +69,181 @@ func parseBoolQuery(val string) bool {
 	return err == nil && b
 }
 
-func CreateContactHandler(db *database.Queries) http.HandlerFunc {
+// contactFromRequest loads the contact named by the {id} path parameter,
+// writing a JSON error and returning false if it does not belong to the
+// organization.
+func contactFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.Contact, bool) {
+	contactID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid contact ID")
+		return database.Contact{}, false
+	}
+
+	contact, err := db.GetContactByID(r.Context(), database.GetContactByIDParams{
+		ID:             contactID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Contact not found")
+			return database.Contact{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch contact")
+		return database.Contact{}, false
+	}
+	return contact, true
+}
+
+// ContactUsage counts the contacts a user has created for the contacts limit.
+// It goes by created_by rather than the owner, which any member can change,
+// so assigning contacts to a teammate or to nobody doesn't free up room. The
+// limit applies whichever organization they are in, and trashed contacts
+// don't count.
+func ContactUsage(db *database.Queries) middleware.UsageFunc {
+	return func(ctx context.Context, user *database.User) (int64, error) {
+		return db.CountContactsCreatedBy(ctx, uuid.NullUUID{UUID: user.ID, Valid: true})
+	}
+}
+
+// reserveContacts checks that the user's plan has room for n more contacts.
+// Call it in the transaction that creates them: it holds a per-user lock
+// until commit, so concurrent creates and imports can't both use the same
+// room.
+func reserveContacts(ctx context.Context, qtx *database.Queries, plans *entitlements.Registry, user *database.User, n int64) error {
+	if _, limited := plans.Limit(user.Plan, entitlements.LimitContacts); !limited {
+		return nil
+	}
+	if err := qtx.LockContactLimit(ctx, user.ID); err != nil {
+		return err
+	}
+
+	used, err := ContactUsage(qtx)(ctx, user)
+	if err != nil {
+		return err
+	}
+	return plans.CheckLimit(user.Plan, entitlements.LimitContacts, used, n)
+}
+
+// CreateContactHandler adds a contact to the current organization. The route
+// checks the contacts limit up front with middleware.CheckLimit; it is
+// checked again under lock when the contact is created.
+func CreateContactHandler(conn *sql.DB, db *database.Queries, plans *entitlements.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := middleware.GetUserFromContext(r.Context())
+		user, org, ok := currentMember(r.Context())
 		if !ok {
 			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
-		count, err := db.CountContactsByUser(r.Context(), user.ID)
+		var req CreateContactRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Contact name is required")
+			return
+		}
+
+		defs, err := db.GetCustomFieldsByOrganization(r.Context(), org.ID())
 		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "Failed to fetch count")
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
 			return
 		}
 
-		if user.Plan == "free" && count >= 100 {
-			http.Error(w, "Contact limit reached. Upgrade to Pro.", http.StatusForbidden)
+		customValues, err := resolveCustomValues(defs, req.CustomFields, true)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		var req CreateContactRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+		if !checkCompanyOwnership(w, r, db, org.ID(), req.CompanyID) {
 			return
 		}
 
-		req.Name = strings.TrimSpace(req.Name)
-		if req.Name == "" {
-			WriteJSONError(w, http.StatusBadRequest, "Contact name is required")
+		owner := uuid.NullUUID{UUID: user.ID, Valid: true}
+		if req.OwnerID != nil {
+			if owner, err = parseMemberID(r.Context(), db, org.ID(), *req.OwnerID); err != nil {
+				writeMemberIDError(w, "owner", err)
+				return
+			}
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create contact")
 			return
 		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
 
-		contact, err := db.CreateContact(r.Context(), database.CreateContactParams{
-			UserID:   user.ID,
-			Name:     req.Name,
-			Email:    ToNullString(req.Email),
-			Phone:    ToNullString(req.Phone),
-			Company:  ToNullString(req.Company),
-			Position: ToNullString(req.Position),
-			Notes:    ToNullString(req.Notes),
+		var limitErr *entitlements.LimitError
+		if err := reserveContacts(r.Context(), qtx, plans, user, 1); errors.As(err, &limitErr) {
+			http.Error(w, limitErr.Message(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create contact")
+			return
+		}
+
+		contact, err := qtx.CreateContact(r.Context(), database.CreateContactParams{
+			OrganizationID: org.ID(),
+			OwnerID:        owner,
+			CreatedBy:      uuid.NullUUID{UUID: user.ID, Valid: true},
+			Name:           req.Name,
+			Email:          ToNullString(req.Email),
+			Phone:          ToNullString(req.Phone),
+			Company:        ToNullString(req.Company),
+			Position:       ToNullString(req.Position),
+			Notes:          ToNullString(req.Notes),
 		})
 		if err != nil {
 			WriteJSONError(w, http.StatusInternalServerError, "Could not create contact")
 			return
 		}
 
+		if err := saveCustomValues(r.Context(), qtx, contact.ID, customValues); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not save custom fields")
+			return
+		}
+
+		if req.CompanyID != nil {
+			err = setExplicitCompany(r.Context(), qtx, &contact, *req.CompanyID)
+		} else {
+			err = autoAssociateCompany(r.Context(), qtx, &contact)
+		}
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not link company")
+			return
+		}
+
+		if err := recordContactCreated(r.Context(), qtx, contact, user.ID, "manual"); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create contact")
+			return
+		}
+
+		resp := []ContactResponse{NewContactResponse(contact)}
+		if err := attachCustomFields(r.Context(), db, defs, resp); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(NewContactResponse(contact))
+		json.NewEncoder(w).Encode(resp[0])
 	}
 }
 
 func GetContactsHandler(db *database.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := middleware.GetUserFromContext(r.Context())
+		_, org, ok := currentMember(r.Context())
 		if !ok {
 			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
 			return
@@ -128,8 +273,32 @@ func GetContactsHandler(db *database.Queries) http.HandlerFunc {
 		requireNonEmptyEmail := parseBoolQuery(query.Get("require_non_empty_email"))
 		requireNonEmptyPosition := parseBoolQuery(query.Get("require_non_empty_position"))
 
+		// Parse tag filter: tags=lead,customer&tag_match=all|any
+		tags := []string{}
+		for _, raw := range query["tags"] {
+			for _, name := range strings.Split(raw, ",") {
+				if name = normalizeTagName(name); name != "" {
+					tags = append(tags, name)
+				}
+			}
+		}
+		matchAllTags := strings.EqualFold(query.Get("tag_match"), "all")
+
+		defs, err := db.GetCustomFieldsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
+		// Parse custom field filters: cf.<key>=value
+		customKeys, customValues, err := customFieldFilters(defs, query)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		contacts, err := db.GetContactsPaginated(r.Context(), database.GetContactsPaginatedParams{
-			UserID:                  user.ID,
+			OrganizationID:          org.ID(),
 			After:                   after,
 			Limit:                   int32(limit),
 			Search:                  search,
@@ -137,12 +306,26 @@ func GetContactsHandler(db *database.Queries) http.HandlerFunc {
 			RequireNonEmptyPhone:    requireNonEmptyPhone,
 			RequireNonEmptyEmail:    requireNonEmptyEmail,
 			RequireNonEmptyPosition: requireNonEmptyPosition,
+			Tags:                    tags,
+			MatchAllTags:            matchAllTags,
+			CustomFieldKeys:         customKeys,
+			CustomFieldValues:       customValues,
 		})
 		if err != nil {
 			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch contacts")
 			return
 		}
 
+		list := NewContactResponseList(contacts)
+		if err := attachTags(r.Context(), db, list); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch contact tags")
+			return
+		}
+		if err := attachCustomFields(r.Context(), db, defs, list); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
 		// Determine next cursor
 		var nextCursor *int64
 		if len(contacts) == int(limit) {
@@ -152,7 +335,7 @@ func GetContactsHandler(db *database.Queries) http.HandlerFunc {
 
 		// Build response
 		resp := map[string]any{
-			"contacts":    NewContactResponseList(contacts),
+			"contacts":    list,
 			"next_cursor": nextCursor,
 		}
 
@@ -161,9 +344,9 @@ func GetContactsHandler(db *database.Queries) http.HandlerFunc {
 	}
 }
 
-func UpdateContactHandler(db *database.Queries) http.HandlerFunc {
+func UpdateContactHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := middleware.GetUserFromContext(r.Context())
+		user, org, ok := currentMember(r.Context())
 		if !ok {
 			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
 			return
@@ -184,8 +367,8 @@ func UpdateContactHandler(db *database.Queries) http.HandlerFunc {
 
 		// Get the existing contact
 		existing, err := db.GetContactByID(r.Context(), database.GetContactByIDParams{
-			ID:     contactID,
-			UserID: user.ID,
+			ID:             contactID,
+			OrganizationID: org.ID(),
 		})
 		if err != nil {
 			WriteJSONError(w, http.StatusNotFound, "Contact not found")
@@ -206,29 +389,105 @@ func UpdateContactHandler(db *database.Queries) http.HandlerFunc {
 			return oldVal
 		}
 
-		updated, err := db.UpdateContact(r.Context(), database.UpdateContactParams{
-			ID:       contactID,
-			UserID:   user.ID,
-			Name:     name,
-			Email:    choose(req.Email, existing.Email),
-			Phone:    choose(req.Phone, existing.Phone),
-			Company:  choose(req.Company, existing.Company),
-			Position: choose(req.Position, existing.Position),
-			Notes:    choose(req.Notes, existing.Notes),
+		defs, err := db.GetCustomFieldsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
+		customValues, err := resolveCustomValues(defs, req.CustomFields, false)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !checkCompanyOwnership(w, r, db, org.ID(), req.CompanyID) {
+			return
+		}
+
+		owner := existing.OwnerID
+		if req.OwnerID != nil {
+			if owner, err = parseMemberID(r.Context(), db, org.ID(), *req.OwnerID); err != nil {
+				writeMemberIDError(w, "owner", err)
+				return
+			}
+		}
+
+		oldValues, err := loadCustomValues(r.Context(), db, []int64{existing.ID})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update contact")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		updated, err := qtx.UpdateContact(r.Context(), database.UpdateContactParams{
+			ID:             contactID,
+			OrganizationID: org.ID(),
+			OwnerID:        owner,
+			Name:           name,
+			Email:          choose(req.Email, existing.Email),
+			Phone:          choose(req.Phone, existing.Phone),
+			Company:        choose(req.Company, existing.Company),
+			Position:       choose(req.Position, existing.Position),
+			Notes:          choose(req.Notes, existing.Notes),
 		})
 		if err != nil {
 			WriteJSONError(w, http.StatusInternalServerError, "Could not update contact")
 			return
 		}
 
+		if err := saveCustomValues(r.Context(), qtx, updated.ID, customValues); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not save custom fields")
+			return
+		}
+
+		// An automatic link is only revisited when the email changes, so
+		// editing other fields doesn't move a contact between companies
+		switch {
+		case req.CompanyID != nil:
+			err = setExplicitCompany(r.Context(), qtx, &updated, *req.CompanyID)
+		case !updated.CompanyID.Valid || updated.Email != existing.Email:
+			err = autoAssociateCompany(r.Context(), qtx, &updated)
+		}
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not link company")
+			return
+		}
+
+		changes := append(activity.DiffContacts(existing, updated), customValueChanges(defs, oldValues[existing.ID], customValues)...)
+		if err := activity.RecordFieldChanges(r.Context(), qtx, updated, user.ID, changes); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update contact")
+			return
+		}
+
+		resp := []ContactResponse{NewContactResponse(updated)}
+		if err := attachCustomFields(r.Context(), db, defs, resp); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(NewContactResponse(updated))
+		json.NewEncoder(w).Encode(resp[0])
 	}
 }
 
-func DeleteContactHandler(db *database.Queries) http.HandlerFunc {
+// DeleteContactHandler moves a contact to the trash. It can be restored until
+// it is purged, either by hand or by the worker once retention runs out.
+func DeleteContactHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := middleware.GetUserFromContext(r.Context())
+		user, org, ok := currentMember(r.Context())
 		if !ok {
 			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
 			return
@@ -241,11 +500,40 @@ func DeleteContactHandler(db *database.Queries) http.HandlerFunc {
 			return
 		}
 
-		err = db.DeleteContact(r.Context(), database.DeleteContactParams{
-			ID:     contactID,
-			UserID: user.ID,
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete contact")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		deleted, err := qtx.SoftDeleteContact(r.Context(), database.SoftDeleteContactParams{
+			ID:             contactID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete contact")
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, http.StatusNotFound, "Contact not found")
+			return
+		}
+
+		_, err = activity.Record(r.Context(), qtx, activity.Entry{
+			OrganizationID: org.ID(),
+			ContactID:      contactID,
+			ActorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+			Kind:           activity.KindContactTrashed,
+			Summary:        "Moved to trash",
 		})
 		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
 			WriteJSONError(w, http.StatusInternalServerError, "Could not delete contact")
 			return
 		}
@@ -254,22 +542,35 @@ func DeleteContactHandler(db *database.Queries) http.HandlerFunc {
 	}
 }
 
+// ExportContactsCSVHandler downloads the organization's contacts. It is a
+// plan feature, gated by middleware.RequireFeature.
 func ExportContactsCSVHandler(q *database.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := middleware.GetUserFromContext(r.Context())
+		_, org, ok := currentMember(r.Context())
 		if !ok {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		if user.Plan != "pro" {
-			http.Error(w, "CSV export is only available to Pro users", http.StatusForbidden)
+		contacts, err := q.GetContactsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			http.Error(w, "Failed to fetch contacts", http.StatusInternalServerError)
 			return
 		}
 
-		contacts, err := q.GetContactsByUser(r.Context(), user.ID)
+		defs, err := q.GetCustomFieldsByOrganization(r.Context(), org.ID())
 		if err != nil {
-			http.Error(w, "Failed to fetch contacts", http.StatusInternalServerError)
+			http.Error(w, "Failed to fetch custom fields", http.StatusInternalServerError)
+			return
+		}
+
+		ids := make([]int64, len(contacts))
+		for i, c := range contacts {
+			ids[i] = c.ID
+		}
+		customValues, err := loadCustomValues(r.Context(), q, ids)
+		if err != nil {
+			http.Error(w, "Failed to fetch custom fields", http.StatusInternalServerError)
 			return
 		}
 
@@ -280,10 +581,14 @@ func ExportContactsCSVHandler(q *database.Queries) http.HandlerFunc {
 		defer writer.Flush()
 
 		// Write header
-		writer.Write([]string{"Name", "Email", "Phone", "Company", "Position", "Notes", "CreatedAt"})
+		header := []string{"Name", "Email", "Phone", "Company", "Position", "Notes", "CreatedAt"}
+		for _, f := range defs {
+			header = append(header, f.Name)
+		}
+		writer.Write(header)
 
 		for _, c := range contacts {
-			writer.Write([]string{
+			record := []string{
 				c.Name,
 				nullString(c.Email),
 				nullString(c.Phone),
@@ -291,7 +596,11 @@ func ExportContactsCSVHandler(q *database.Queries) http.HandlerFunc {
 				nullString(c.Position),
 				nullString(c.Notes),
 				c.CreatedAt.Format("2006-01-02 15:04"),
-			})
+			}
+			for _, f := range defs {
+				record = append(record, customValues[c.ID][f.ID])
+			}
+			writer.Write(record)
 		}
 	}
 }
@@ -0,0 +1,523 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	CustomFieldText    = "text"
+	CustomFieldNumber  = "number"
+	CustomFieldDate    = "date"
+	CustomFieldSelect  = "select"
+	CustomFieldBoolean = "boolean"
+
+	customFieldDateLayout  = "2006-01-02"
+	maxCustomTextLength    = 1000
+	customFieldQueryPrefix = "cf."
+)
+
+var (
+	customFieldTypes  = []string{CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldSelect, CustomFieldBoolean}
+	customFieldKeyExp = regexp.MustCompile(`^[a-z0-9_]+$`)
+	nonKeyChars       = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func NewCustomFieldResponse(f database.CustomField) CustomFieldResponse {
+	options := f.Options
+	if options == nil {
+		options = []string{}
+	}
+	return CustomFieldResponse{
+		ID:        f.ID,
+		Name:      f.Name,
+		Key:       f.Key,
+		Type:      f.FieldType,
+		Options:   options,
+		Required:  f.Required,
+		Position:  f.Position,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+func customFieldKeyFromName(name string) string {
+	return strings.Trim(nonKeyChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// normalizeCustomValue validates a raw JSON or query-string value against the
+// field type and returns the canonical text form stored in the database.
+func normalizeCustomValue(f database.CustomField, v any) (string, error) {
+	switch f.FieldType {
+	case CustomFieldText:
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("%s must be text", f.Name)
+		}
+		s = strings.TrimSpace(s)
+		if len(s) > maxCustomTextLength {
+			return "", fmt.Errorf("%s is too long", f.Name)
+		}
+		return s, nil
+
+	case CustomFieldNumber:
+		switch n := v.(type) {
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		case string:
+			// ParseFloat also accepts NaN and Inf, which aren't numbers
+			// anyone can filter or sort by
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+				return "", fmt.Errorf("%s must be a number", f.Name)
+			}
+			return strconv.FormatFloat(parsed, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("%s must be a number", f.Name)
+
+	case CustomFieldDate:
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("%s must be a date (YYYY-MM-DD)", f.Name)
+		}
+		d, err := time.Parse(customFieldDateLayout, strings.TrimSpace(s))
+		if err != nil {
+			return "", fmt.Errorf("%s must be a date (YYYY-MM-DD)", f.Name)
+		}
+		return d.Format(customFieldDateLayout), nil
+
+	case CustomFieldSelect:
+		s, ok := v.(string)
+		if !ok || !slices.Contains(f.Options, strings.TrimSpace(s)) {
+			return "", fmt.Errorf("%s must be one of: %s", f.Name, strings.Join(f.Options, ", "))
+		}
+		return strings.TrimSpace(s), nil
+
+	case CustomFieldBoolean:
+		switch b := v.(type) {
+		case bool:
+			return strconv.FormatBool(b), nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+			if err != nil {
+				return "", fmt.Errorf("%s must be true or false", f.Name)
+			}
+			return strconv.FormatBool(parsed), nil
+		}
+		return "", fmt.Errorf("%s must be true or false", f.Name)
+	}
+
+	return "", fmt.Errorf("%s has unsupported type %s", f.Name, f.FieldType)
+}
+
+// decodeCustomValue converts a stored text value back to its JSON type.
+func decodeCustomValue(f database.CustomField, s string) any {
+	switch f.FieldType {
+	case CustomFieldNumber:
+		if n, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+			return n
+		}
+	case CustomFieldBoolean:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+// resolveCustomValues validates the custom_fields object of a contact request.
+// The result maps field IDs to their new value, with nil meaning "clear".
+// When creating is true, every required field must be supplied.
+func resolveCustomValues(defs []database.CustomField, input map[string]any, creating bool) (map[int64]*string, error) {
+	byKey := make(map[string]database.CustomField, len(defs))
+	for _, f := range defs {
+		byKey[f.Key] = f
+	}
+
+	values := make(map[int64]*string, len(input))
+	for key, raw := range input {
+		f, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("Unknown custom field: %s", key)
+		}
+
+		if s, isString := raw.(string); raw == nil || (isString && strings.TrimSpace(s) == "") {
+			if f.Required {
+				return nil, fmt.Errorf("%s is required", f.Name)
+			}
+			values[f.ID] = nil
+			continue
+		}
+
+		v, err := normalizeCustomValue(f, raw)
+		if err != nil {
+			return nil, err
+		}
+		values[f.ID] = &v
+	}
+
+	if creating {
+		for _, f := range defs {
+			if f.Required && values[f.ID] == nil {
+				return nil, fmt.Errorf("%s is required", f.Name)
+			}
+		}
+	}
+	return values, nil
+}
+
+func saveCustomValues(ctx context.Context, db *database.Queries, contactID int64, values map[int64]*string) error {
+	for fieldID, v := range values {
+		var err error
+		if v == nil {
+			err = db.DeleteContactCustomValue(ctx, database.DeleteContactCustomValueParams{
+				ContactID: contactID,
+				FieldID:   fieldID,
+			})
+		} else {
+			err = db.UpsertContactCustomValue(ctx, database.UpsertContactCustomValueParams{
+				ContactID: contactID,
+				FieldID:   fieldID,
+				Value:     *v,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customFieldFilters turns cf.<key>=value query parameters into the parallel
+// key/value arrays expected by GetContactsPaginated.
+func customFieldFilters(defs []database.CustomField, query map[string][]string) ([]string, []string, error) {
+	byKey := make(map[string]database.CustomField, len(defs))
+	for _, f := range defs {
+		byKey[f.Key] = f
+	}
+
+	var keys, values []string
+	for param, raw := range query {
+		key, ok := strings.CutPrefix(param, customFieldQueryPrefix)
+		if !ok {
+			continue
+		}
+		f, ok := byKey[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("Unknown custom field: %s", key)
+		}
+		for _, r := range raw {
+			v, err := normalizeCustomValue(f, r)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, v)
+		}
+	}
+	return keys, values, nil
+}
+
+// loadCustomValues returns the stored custom values of the given contacts,
+// keyed by contact ID and then by field.
+func loadCustomValues(ctx context.Context, db *database.Queries, ids []int64) (map[int64]map[int64]string, error) {
+	result := make(map[int64]map[int64]string, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.GetCustomValuesByContactIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if result[row.ContactID] == nil {
+			result[row.ContactID] = map[int64]string{}
+		}
+		result[row.ContactID][row.FieldID] = row.Value
+	}
+	return result, nil
+}
+
+// attachCustomFields fills in the CustomFields of each contact using the
+// given definitions.
+func attachCustomFields(ctx context.Context, db *database.Queries, defs []database.CustomField, contacts []ContactResponse) error {
+	if len(defs) == 0 || len(contacts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(contacts))
+	for i, c := range contacts {
+		ids[i] = c.ID
+	}
+
+	values, err := loadCustomValues(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range contacts {
+		stored := values[contacts[i].ID]
+		if len(stored) == 0 {
+			continue
+		}
+		contacts[i].CustomFields = map[string]any{}
+		for _, f := range defs {
+			if v, ok := stored[f.ID]; ok {
+				contacts[i].CustomFields[f.Key] = decodeCustomValue(f, v)
+			}
+		}
+	}
+	return nil
+}
+
+// CustomValuesByKey returns the stored custom values of one contact keyed by
+// field key, for rendering in templates.
+func CustomValuesByKey(ctx context.Context, db *database.Queries, defs []database.CustomField, contactID int64) (map[string]string, error) {
+	values, err := loadCustomValues(ctx, db, []int64{contactID})
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]string{}
+	for _, f := range defs {
+		if v, ok := values[contactID][f.ID]; ok {
+			byKey[f.Key] = v
+		}
+	}
+	return byKey, nil
+}
+
+func validateCustomFieldOptions(fieldType string, options []string) ([]string, error) {
+	cleaned := make([]string, 0, len(options))
+	for _, o := range options {
+		o = strings.TrimSpace(o)
+		if o != "" && !slices.Contains(cleaned, o) {
+			cleaned = append(cleaned, o)
+		}
+	}
+	if fieldType == CustomFieldSelect && len(cleaned) == 0 {
+		return nil, errors.New("Select fields need at least one option")
+	}
+	if fieldType != CustomFieldSelect {
+		return []string{}, nil
+	}
+	return cleaned, nil
+}
+
+func customFieldFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.CustomField, bool) {
+	fieldID, err := strconv.ParseInt(r.PathValue("fieldID"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid field ID")
+		return database.CustomField{}, false
+	}
+
+	field, err := db.GetCustomFieldByID(r.Context(), database.GetCustomFieldByIDParams{
+		ID:             fieldID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Custom field not found")
+			return database.CustomField{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom field")
+		return database.CustomField{}, false
+	}
+	return field, true
+}
+
+func GetCustomFieldsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		fields, err := db.GetCustomFieldsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
+		resp := make([]CustomFieldResponse, len(fields))
+		for i, f := range fields {
+			resp[i] = NewCustomFieldResponse(f)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"fields": resp})
+	}
+}
+
+func CreateCustomFieldHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req CreateCustomFieldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Field name is required")
+			return
+		}
+
+		req.Key = strings.ToLower(strings.TrimSpace(req.Key))
+		if req.Key == "" {
+			req.Key = customFieldKeyFromName(req.Name)
+		}
+		if !customFieldKeyExp.MatchString(req.Key) {
+			WriteJSONError(w, http.StatusBadRequest, "Field key may only contain lowercase letters, digits and underscores")
+			return
+		}
+
+		if !slices.Contains(customFieldTypes, req.Type) {
+			WriteJSONError(w, http.StatusBadRequest, "Field type must be one of: "+strings.Join(customFieldTypes, ", "))
+			return
+		}
+
+		options, err := validateCustomFieldOptions(req.Type, req.Options)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		field, err := db.CreateCustomField(r.Context(), database.CreateCustomFieldParams{
+			OrganizationID: org.ID(),
+			Name:           req.Name,
+			Key:            req.Key,
+			FieldType:      req.Type,
+			Options:        options,
+			Required:       req.Required,
+			Position:       req.Position,
+		})
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+				WriteJSONError(w, http.StatusBadRequest, "A field with this key already exists")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create custom field")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(NewCustomFieldResponse(field))
+	}
+}
+
+func UpdateCustomFieldHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		existing, ok := customFieldFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req PatchCustomFieldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name := existing.Name
+		if req.Name != nil {
+			name = strings.TrimSpace(*req.Name)
+			if name == "" {
+				WriteJSONError(w, http.StatusBadRequest, "Field name is required")
+				return
+			}
+		}
+
+		options := existing.Options
+		if req.Options != nil {
+			var err error
+			options, err = validateCustomFieldOptions(existing.FieldType, req.Options)
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		required := existing.Required
+		if req.Required != nil {
+			required = *req.Required
+		}
+
+		position := existing.Position
+		if req.Position != nil {
+			position = *req.Position
+		}
+
+		field, err := db.UpdateCustomField(r.Context(), database.UpdateCustomFieldParams{
+			ID:             existing.ID,
+			OrganizationID: org.ID(),
+			Name:           name,
+			Options:        options,
+			Required:       required,
+			Position:       position,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update custom field")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewCustomFieldResponse(field))
+	}
+}
+
+func DeleteCustomFieldHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		field, ok := customFieldFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		err := db.DeleteCustomField(r.Context(), database.DeleteCustomFieldParams{
+			ID:             field.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete custom field")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,1160 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPipelineName = "Sales Pipeline"
+	defaultCurrency     = "USD"
+	dealDateLayout      = "2006-01-02"
+
+	// maxDealAmount keeps amounts well within what cents fit in an int64
+	maxDealAmount = 1e12
+)
+
+var (
+	defaultStages = []string{"Lead", "Qualified", "Proposal", "Negotiation", "Won", "Lost"}
+	currencyExp   = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// DealBoard is the data behind the deals page: one column per stage.
+type DealBoard struct {
+	Pipelines []database.Pipeline
+	Pipeline  database.Pipeline
+	Stages    []database.PipelineStage
+	Columns   []DealBoardColumn
+}
+
+type DealBoardColumn struct {
+	Stage database.PipelineStage
+	Deals []DealResponse
+	Total float64
+}
+
+func NewDealResponse(d database.Deal) DealResponse {
+	resp := DealResponse{
+		ID:         d.ID,
+		PipelineID: d.PipelineID,
+		StageID:    d.StageID,
+		Title:      d.Title,
+		Amount:     centsToAmount(d.AmountCents),
+		Currency:   d.Currency,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+	if d.CloseDate.Valid {
+		resp.CloseDate = d.CloseDate.Time.Format(dealDateLayout)
+	}
+	if d.OwnerID.Valid {
+		resp.OwnerID = d.OwnerID.UUID.String()
+	}
+	return resp
+}
+
+func NewPipelineResponse(p database.Pipeline, stages []database.PipelineStage) PipelineResponse {
+	resp := PipelineResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Stages:    make([]StageResponse, len(stages)),
+		CreatedAt: p.CreatedAt,
+	}
+	for i, s := range stages {
+		resp.Stages[i] = StageResponse{ID: s.ID, Name: s.Name, Position: s.Position}
+	}
+	return resp
+}
+
+func amountToCents(amount float64) (int64, error) {
+	if amount < 0 {
+		return 0, errors.New("amount can't be negative")
+	}
+	if amount > maxDealAmount {
+		return 0, errors.New("amount is too large")
+	}
+	return int64(math.Round(amount * 100)), nil
+}
+
+func centsToAmount(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+func parseCloseDate(s string) (sql.NullTime, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(dealDateLayout, s)
+	if err != nil {
+		return sql.NullTime{}, errors.New("close_date must be a date (YYYY-MM-DD)")
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+func normalizeCurrency(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return defaultCurrency, nil
+	}
+	if !currencyExp.MatchString(s) {
+		return "", errors.New("currency must be a three-letter ISO code")
+	}
+	return s, nil
+}
+
+func createPipelineWithStages(ctx context.Context, qtx *database.Queries, orgID, userID uuid.UUID, name string, stages []string) (database.Pipeline, error) {
+	pipeline, err := qtx.CreatePipeline(ctx, database.CreatePipelineParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Name:           name,
+	})
+	if err != nil {
+		return database.Pipeline{}, err
+	}
+
+	for i, stage := range stages {
+		_, err := qtx.CreatePipelineStage(ctx, database.CreatePipelineStageParams{
+			PipelineID: pipeline.ID,
+			Name:       stage,
+			Position:   int32(i),
+		})
+		if err != nil {
+			return database.Pipeline{}, err
+		}
+	}
+	return pipeline, nil
+}
+
+// EnsureDefaultPipeline gives every organization a starter pipeline the first
+// time one of its members opens the deals board, so free users get their one
+// pipeline for free.
+func EnsureDefaultPipeline(ctx context.Context, conn *sql.DB, db *database.Queries, orgID, userID uuid.UUID) error {
+	count, err := db.CountPipelinesByOrganization(ctx, orgID)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := db.WithTx(tx)
+
+	// Members opening the board at the same time would each create one
+	if _, err := qtx.LockOrganization(ctx, orgID); err != nil {
+		return err
+	}
+	if count, err := qtx.CountPipelinesByOrganization(ctx, orgID); err != nil || count > 0 {
+		return err
+	}
+
+	if _, err := createPipelineWithStages(ctx, qtx, orgID, userID, defaultPipelineName, defaultStages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BuildDealBoard loads the selected pipeline (or the first one when
+// pipelineID is 0) with its deals grouped by stage.
+func BuildDealBoard(ctx context.Context, db *database.Queries, orgID uuid.UUID, pipelineID int64) (DealBoard, error) {
+	var board DealBoard
+
+	pipelines, err := db.GetPipelinesByOrganization(ctx, orgID)
+	if err != nil {
+		return board, err
+	}
+	board.Pipelines = pipelines
+	if len(pipelines) == 0 {
+		return board, nil
+	}
+
+	board.Pipeline = pipelines[0]
+	for _, p := range pipelines {
+		if p.ID == pipelineID {
+			board.Pipeline = p
+		}
+	}
+
+	stages, err := db.GetStagesByPipeline(ctx, board.Pipeline.ID)
+	if err != nil {
+		return board, err
+	}
+	board.Stages = stages
+
+	deals, err := db.GetDealsByPipeline(ctx, database.GetDealsByPipelineParams{
+		PipelineID:     board.Pipeline.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return board, err
+	}
+
+	byStage := map[int64]int{}
+	board.Columns = make([]DealBoardColumn, len(stages))
+	for i, s := range stages {
+		board.Columns[i] = DealBoardColumn{Stage: s}
+		byStage[s.ID] = i
+	}
+	for _, d := range deals {
+		if i, ok := byStage[d.StageID]; ok {
+			board.Columns[i].Deals = append(board.Columns[i].Deals, NewDealResponse(d))
+			board.Columns[i].Total += centsToAmount(d.AmountCents)
+		}
+	}
+	return board, nil
+}
+
+// recordDealActivity puts a deal event on the timeline of each given contact.
+func recordDealActivity(ctx context.Context, db *database.Queries, actor uuid.UUID, contacts []database.Contact, kind, summary string, details map[string]any) error {
+	for _, c := range contacts {
+		_, err := activity.Record(ctx, db, activity.Entry{
+			OrganizationID: c.OrganizationID,
+			ContactID:      c.ID,
+			ActorID:        uuid.NullUUID{UUID: actor, Valid: true},
+			Kind:           kind,
+			Summary:        summary,
+			Details:        details,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pipelineFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.Pipeline, bool) {
+	pipelineID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid pipeline ID")
+		return database.Pipeline{}, false
+	}
+
+	pipeline, err := db.GetPipelineByID(r.Context(), database.GetPipelineByIDParams{
+		ID:             pipelineID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Pipeline not found")
+			return database.Pipeline{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch pipeline")
+		return database.Pipeline{}, false
+	}
+	return pipeline, true
+}
+
+func stageFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.PipelineStage, bool) {
+	stageID, err := strconv.ParseInt(r.PathValue("stageID"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid stage ID")
+		return database.PipelineStage{}, false
+	}
+
+	stage, err := db.GetStageByID(r.Context(), database.GetStageByIDParams{
+		ID:             stageID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Stage not found")
+			return database.PipelineStage{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch stage")
+		return database.PipelineStage{}, false
+	}
+	return stage, true
+}
+
+func dealFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.Deal, bool) {
+	dealID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid deal ID")
+		return database.Deal{}, false
+	}
+
+	deal, err := db.GetDealByID(r.Context(), database.GetDealByIDParams{
+		ID:             dealID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Deal not found")
+			return database.Deal{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deal")
+		return database.Deal{}, false
+	}
+	return deal, true
+}
+
+func GetPipelinesHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		pipelines, err := db.GetPipelinesByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch pipelines")
+			return
+		}
+
+		resp := make([]PipelineResponse, len(pipelines))
+		for i, p := range pipelines {
+			stages, err := db.GetStagesByPipeline(r.Context(), p.ID)
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not fetch stages")
+				return
+			}
+			resp[i] = NewPipelineResponse(p, stages)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"pipelines": resp})
+	}
+}
+
+func CreatePipelineHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req CreatePipelineRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Pipeline name is required")
+			return
+		}
+
+		stages := make([]string, 0, len(req.Stages))
+		for _, s := range req.Stages {
+			if s = strings.TrimSpace(s); s != "" {
+				stages = append(stages, s)
+			}
+		}
+		if len(stages) == 0 {
+			stages = defaultStages
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create pipeline")
+			return
+		}
+		defer tx.Rollback()
+
+		pipeline, err := createPipelineWithStages(r.Context(), db.WithTx(tx), org.ID(), user.ID, req.Name, stages)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create pipeline")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create pipeline")
+			return
+		}
+
+		created, err := db.GetStagesByPipeline(r.Context(), pipeline.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch stages")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(NewPipelineResponse(pipeline, created))
+	}
+}
+
+func UpdatePipelineHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		existing, ok := pipelineFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req CreatePipelineRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Pipeline name is required")
+			return
+		}
+
+		pipeline, err := db.UpdatePipeline(r.Context(), database.UpdatePipelineParams{
+			ID:             existing.ID,
+			OrganizationID: org.ID(),
+			Name:           req.Name,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update pipeline")
+			return
+		}
+
+		stages, err := db.GetStagesByPipeline(r.Context(), pipeline.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch stages")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewPipelineResponse(pipeline, stages))
+	}
+}
+
+func DeletePipelineHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		pipeline, ok := pipelineFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		deals, err := db.GetDealsByPipeline(r.Context(), database.GetDealsByPipelineParams{
+			PipelineID:     pipeline.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deals")
+			return
+		}
+		if len(deals) > 0 {
+			WriteJSONError(w, http.StatusConflict, "Move or delete the deals in this pipeline first")
+			return
+		}
+
+		err = db.DeletePipeline(r.Context(), database.DeletePipelineParams{
+			ID:             pipeline.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete pipeline")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func CreateStageHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		pipeline, ok := pipelineFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req StageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name := ""
+		if req.Name != nil {
+			name = strings.TrimSpace(*req.Name)
+		}
+		if name == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Stage name is required")
+			return
+		}
+
+		// New stages go last unless a position is given
+		stages, err := db.GetStagesByPipeline(r.Context(), pipeline.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch stages")
+			return
+		}
+		position := int32(len(stages))
+		if len(stages) > 0 {
+			position = stages[len(stages)-1].Position + 1
+		}
+		if req.Position != nil {
+			position = *req.Position
+		}
+
+		stage, err := db.CreatePipelineStage(r.Context(), database.CreatePipelineStageParams{
+			PipelineID: pipeline.ID,
+			Name:       name,
+			Position:   position,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create stage")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(StageResponse{ID: stage.ID, Name: stage.Name, Position: stage.Position})
+	}
+}
+
+func UpdateStageHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		existing, ok := stageFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req StageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name := existing.Name
+		if req.Name != nil {
+			name = strings.TrimSpace(*req.Name)
+			if name == "" {
+				WriteJSONError(w, http.StatusBadRequest, "Stage name is required")
+				return
+			}
+		}
+
+		position := existing.Position
+		if req.Position != nil {
+			position = *req.Position
+		}
+
+		stage, err := db.UpdatePipelineStage(r.Context(), database.UpdatePipelineStageParams{
+			ID:       existing.ID,
+			Name:     name,
+			Position: position,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update stage")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StageResponse{ID: stage.ID, Name: stage.Name, Position: stage.Position})
+	}
+}
+
+func DeleteStageHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		stage, ok := stageFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		count, err := db.CountDealsByStage(r.Context(), stage.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deals")
+			return
+		}
+		if count > 0 {
+			WriteJSONError(w, http.StatusConflict, "Move the deals in this stage first")
+			return
+		}
+
+		if err := db.DeletePipelineStage(r.Context(), stage.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete stage")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetDealsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		pipelineID, _ := strconv.ParseInt(r.URL.Query().Get("pipeline_id"), 10, 64)
+		board, err := BuildDealBoard(r.Context(), db, org.ID(), pipelineID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deals")
+			return
+		}
+
+		deals := []DealResponse{}
+		for _, col := range board.Columns {
+			deals = append(deals, col.Deals...)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"pipeline_id": board.Pipeline.ID,
+			"deals":       deals,
+		})
+	}
+}
+
+func CreateDealHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req CreateDealRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Title = strings.TrimSpace(req.Title)
+		if req.Title == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Deal title is required")
+			return
+		}
+
+		currency, err := normalizeCurrency(req.Currency)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		closeDate, err := parseCloseDate(req.CloseDate)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		amount, err := amountToCents(req.Amount)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		owner := uuid.NullUUID{UUID: user.ID, Valid: true}
+		if req.OwnerID != nil {
+			if owner, err = parseMemberID(r.Context(), db, org.ID(), *req.OwnerID); err != nil {
+				writeMemberIDError(w, "owner", err)
+				return
+			}
+		}
+
+		stage, err := db.GetStageByID(r.Context(), database.GetStageByIDParams{
+			ID:             req.StageID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Stage not found")
+			return
+		}
+
+		contacts := make([]database.Contact, len(req.ContactIDs))
+		for i, contactID := range req.ContactIDs {
+			contacts[i], err = db.GetContactByID(r.Context(), database.GetContactByIDParams{ID: contactID, OrganizationID: org.ID()})
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, "Contact not found: "+strconv.FormatInt(contactID, 10))
+				return
+			}
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create deal")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		deal, err := qtx.CreateDeal(r.Context(), database.CreateDealParams{
+			OrganizationID: org.ID(),
+			UserID:         user.ID,
+			PipelineID:     stage.PipelineID,
+			StageID:        stage.ID,
+			Title:          req.Title,
+			AmountCents:    amount,
+			Currency:       currency,
+			CloseDate:      closeDate,
+			OwnerID:        owner,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create deal")
+			return
+		}
+
+		err = qtx.CreateDealStageChange(r.Context(), database.CreateDealStageChangeParams{
+			DealID:    deal.ID,
+			ToStageID: sql.NullInt64{Int64: stage.ID, Valid: true},
+			ChangedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record stage history")
+			return
+		}
+
+		for _, contactID := range req.ContactIDs {
+			_, err := qtx.AddContactToDeal(r.Context(), database.AddContactToDealParams{
+				DealID:    deal.ID,
+				ContactID: contactID,
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not link contacts")
+				return
+			}
+		}
+
+		err = recordDealActivity(r.Context(), qtx, user.ID, contacts, activity.KindDealLinked,
+			"Added to deal "+deal.Title, map[string]any{"deal_id": deal.ID})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create deal")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(NewDealResponse(deal))
+	}
+}
+
+func GetDealHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		deal, ok := dealFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		contacts, err := db.GetContactsByDeal(r.Context(), deal.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deal contacts")
+			return
+		}
+
+		history, err := db.GetDealStageHistory(r.Context(), deal.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deal history")
+			return
+		}
+
+		resp := NewDealResponse(deal)
+		resp.Contacts = NewContactResponseList(contacts)
+		resp.History = make([]StageChangeResponse, len(history))
+		for i, h := range history {
+			resp.History[i] = StageChangeResponse{
+				FromStage: h.FromStageName.String,
+				ToStage:   h.ToStageName.String,
+				ChangedAt: h.ChangedAt,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func UpdateDealHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		existing, ok := dealFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req PatchDealRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		title := existing.Title
+		if req.Title != nil {
+			title = strings.TrimSpace(*req.Title)
+			if title == "" {
+				WriteJSONError(w, http.StatusBadRequest, "Deal title is required")
+				return
+			}
+		}
+
+		amount := existing.AmountCents
+		if req.Amount != nil {
+			var err error
+			if amount, err = amountToCents(*req.Amount); err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		owner := existing.OwnerID
+		if req.OwnerID != nil {
+			var err error
+			if owner, err = parseMemberID(r.Context(), db, org.ID(), *req.OwnerID); err != nil {
+				writeMemberIDError(w, "owner", err)
+				return
+			}
+		}
+
+		currency := existing.Currency
+		if req.Currency != nil {
+			var err error
+			if currency, err = normalizeCurrency(*req.Currency); err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		closeDate := existing.CloseDate
+		if req.CloseDate != nil {
+			var err error
+			if closeDate, err = parseCloseDate(*req.CloseDate); err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		stageID, stageName := existing.StageID, ""
+		if req.StageID != nil && *req.StageID != existing.StageID {
+			stage, err := db.GetStageByID(r.Context(), database.GetStageByIDParams{
+				ID:             *req.StageID,
+				OrganizationID: org.ID(),
+			})
+			if err != nil || stage.PipelineID != existing.PipelineID {
+				WriteJSONError(w, http.StatusBadRequest, "Stage not found in this deal's pipeline")
+				return
+			}
+			stageID, stageName = stage.ID, stage.Name
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update deal")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		deal, err := qtx.UpdateDeal(r.Context(), database.UpdateDealParams{
+			ID:             existing.ID,
+			OrganizationID: org.ID(),
+			StageID:        stageID,
+			Title:          title,
+			AmountCents:    amount,
+			Currency:       currency,
+			CloseDate:      closeDate,
+			OwnerID:        owner,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update deal")
+			return
+		}
+
+		if stageID != existing.StageID {
+			err := qtx.CreateDealStageChange(r.Context(), database.CreateDealStageChangeParams{
+				DealID:      deal.ID,
+				FromStageID: sql.NullInt64{Int64: existing.StageID, Valid: true},
+				ToStageID:   sql.NullInt64{Int64: stageID, Valid: true},
+				ChangedBy:   uuid.NullUUID{UUID: user.ID, Valid: true},
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record stage history")
+				return
+			}
+
+			contacts, err := qtx.GetContactsByDeal(r.Context(), deal.ID)
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deal contacts")
+				return
+			}
+			err = recordDealActivity(r.Context(), qtx, user.ID, contacts, activity.KindDealStageChanged,
+				fmt.Sprintf("Deal %s moved to %s", deal.Title, stageName),
+				map[string]any{"deal_id": deal.ID, "from_stage_id": existing.StageID, "to_stage_id": stageID})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update deal")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewDealResponse(deal))
+	}
+}
+
+// DeleteDealHandler deletes a deal. Its contacts keep a note on their
+// timeline that they were on it.
+func DeleteDealHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		deal, ok := dealFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete deal")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		contacts, err := qtx.GetContactsByDeal(r.Context(), deal.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deal contacts")
+			return
+		}
+
+		err = qtx.DeleteDeal(r.Context(), database.DeleteDealParams{
+			ID:             deal.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete deal")
+			return
+		}
+
+		err = recordDealActivity(r.Context(), qtx, user.ID, contacts, activity.KindDealUnlinked,
+			"Deal "+deal.Title+" was deleted", map[string]any{"deal_id": deal.ID})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete deal")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func AddDealContactHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		deal, ok := dealFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req DealContactRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		contact, err := db.GetContactByID(r.Context(), database.GetContactByIDParams{
+			ID:             req.ContactID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Contact not found")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not link contact")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		linked, err := qtx.AddContactToDeal(r.Context(), database.AddContactToDealParams{
+			DealID:    deal.ID,
+			ContactID: contact.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not link contact")
+			return
+		}
+
+		// Re-adding a linked contact is a no-op and stays off the timeline
+		if linked > 0 {
+			err = recordDealActivity(r.Context(), qtx, user.ID, []database.Contact{contact}, activity.KindDealLinked,
+				"Added to deal "+deal.Title, map[string]any{"deal_id": deal.ID})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not link contact")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func RemoveDealContactHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		deal, ok := dealFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		contactID, err := strconv.ParseInt(r.PathValue("contactID"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid contact ID")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not unlink contact")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		contacts, err := qtx.GetContactsByDeal(r.Context(), deal.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deal contacts")
+			return
+		}
+		// Removing a contact that isn't linked is a no-op and stays off the timeline
+		contacts = slices.DeleteFunc(contacts, func(c database.Contact) bool { return c.ID != contactID })
+
+		err = qtx.RemoveContactFromDeal(r.Context(), database.RemoveContactFromDealParams{
+			DealID:    deal.ID,
+			ContactID: contactID,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not unlink contact")
+			return
+		}
+
+		err = recordDealActivity(r.Context(), qtx, user.ID, contacts, activity.KindDealUnlinked,
+			"Removed from deal "+deal.Title, map[string]any{"deal_id": deal.ID})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not unlink contact")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetContactDealsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contactID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid contact ID")
+			return
+		}
+
+		deals, err := db.GetDealsByContact(r.Context(), database.GetDealsByContactParams{
+			ContactID:      contactID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch deals")
+			return
+		}
+
+		resp := make([]DealResponse, len(deals))
+		for i, d := range deals {
+			resp[i] = NewDealResponse(d)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"deals": resp})
+	}
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/dedupe"
+	"github.com/google/uuid"
+)
+
+const (
+	mergeKeepWinner = "winner"
+	mergeKeepLoser  = "loser"
+)
+
+var mergeableFields = []string{"name", "email", "phone", "company", "position"}
+
+// mergeField picks the value of one field: the side named in prefer wins,
+// otherwise the winner's value is kept unless it is empty.
+func mergeField(prefer string, winner, loser sql.NullString) sql.NullString {
+	if prefer == mergeKeepLoser {
+		return loser
+	}
+	if prefer == mergeKeepWinner || (winner.Valid && winner.String != "") {
+		return winner
+	}
+	return loser
+}
+
+// mergeNotes keeps both notes so nothing written on the loser is lost.
+func mergeNotes(winner, loser sql.NullString) sql.NullString {
+	w, l := strings.TrimSpace(winner.String), strings.TrimSpace(loser.String)
+	switch {
+	case l == "" || l == w:
+		return winner
+	case w == "":
+		return loser
+	}
+	return ToNullString(w + "\n\n---\n" + l)
+}
+
+// mergeRelatedRecords re-points everything attached to the loser at the
+// winner, and fills the winner's company link if it has none. It must run
+// inside the merge transaction, before the loser is deleted.
+func mergeRelatedRecords(ctx context.Context, qtx *database.Queries, winner *database.Contact, loser database.Contact) error {
+	if !winner.CompanyID.Valid && loser.CompanyID.Valid {
+		if err := qtx.SetContactCompany(ctx, database.SetContactCompanyParams{
+			ID:                winner.ID,
+			OrganizationID:    winner.OrganizationID,
+			CompanyID:         loser.CompanyID,
+			CompanyAutoLinked: loser.CompanyAutoLinked,
+		}); err != nil {
+			return err
+		}
+		winner.CompanyID = loser.CompanyID
+		winner.CompanyAutoLinked = loser.CompanyAutoLinked
+	}
+	if err := qtx.MoveContactTags(ctx, database.MoveContactTagsParams{
+		WinnerID: winner.ID,
+		LoserID:  loser.ID,
+	}); err != nil {
+		return err
+	}
+	if err := qtx.MoveContactCustomValues(ctx, database.MoveContactCustomValuesParams{
+		WinnerID: winner.ID,
+		LoserID:  loser.ID,
+	}); err != nil {
+		return err
+	}
+	if err := qtx.MoveDealContacts(ctx, database.MoveDealContactsParams{
+		WinnerID: winner.ID,
+		LoserID:  loser.ID,
+	}); err != nil {
+		return err
+	}
+	if err := qtx.MoveContactTasks(ctx, database.MoveContactTasksParams{
+		WinnerID: winner.ID,
+		LoserID:  loser.ID,
+	}); err != nil {
+		return err
+	}
+	return qtx.MoveContactActivities(ctx, database.MoveContactActivitiesParams{
+		WinnerID: winner.ID,
+		LoserID:  loser.ID,
+	})
+}
+
+func GetDuplicateContactsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contacts, err := db.GetContactsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch contacts")
+			return
+		}
+
+		byID := make(map[int64]database.Contact, len(contacts))
+		candidates := make([]dedupe.Candidate, len(contacts))
+		for i, c := range contacts {
+			byID[c.ID] = c
+			candidates[i] = dedupe.Candidate{
+				ID:      c.ID,
+				Name:    c.Name,
+				Email:   c.Email.String,
+				Phone:   c.Phone.String,
+				Company: c.Company.String,
+			}
+		}
+
+		groups := dedupe.FindDuplicates(candidates)
+		resp := make([]DuplicateGroupResponse, len(groups))
+		for i, g := range groups {
+			members := make([]database.Contact, len(g.IDs))
+			for j, id := range g.IDs {
+				members[j] = byID[id]
+			}
+			resp[i] = DuplicateGroupResponse{
+				Reason:   g.Reason,
+				Key:      g.Key,
+				Contacts: NewContactResponseList(members),
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"duplicates": resp})
+	}
+}
+
+func MergeContactsHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req MergeContactsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		if req.WinnerID == 0 || req.LoserID == 0 || req.WinnerID == req.LoserID {
+			WriteJSONError(w, http.StatusBadRequest, "winner_id and loser_id must be two different contacts")
+			return
+		}
+
+		for field, side := range req.Fields {
+			if !slices.Contains(mergeableFields, field) {
+				WriteJSONError(w, http.StatusBadRequest, "Unknown merge field: "+field)
+				return
+			}
+			if side != mergeKeepWinner && side != mergeKeepLoser {
+				WriteJSONError(w, http.StatusBadRequest, "Merge fields must be \"winner\" or \"loser\"")
+				return
+			}
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge contacts")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		winner, err := qtx.GetContactByID(r.Context(), database.GetContactByIDParams{ID: req.WinnerID, OrganizationID: org.ID()})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				WriteJSONError(w, http.StatusNotFound, "Winner contact not found")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge contacts")
+			return
+		}
+		loser, err := qtx.GetContactByID(r.Context(), database.GetContactByIDParams{ID: req.LoserID, OrganizationID: org.ID()})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				WriteJSONError(w, http.StatusNotFound, "Loser contact not found")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge contacts")
+			return
+		}
+
+		name := winner.Name
+		if req.Fields["name"] == mergeKeepLoser {
+			name = loser.Name
+		}
+
+		owner := winner.OwnerID
+		if !owner.Valid {
+			owner = loser.OwnerID
+		}
+
+		merged, err := qtx.UpdateContact(r.Context(), database.UpdateContactParams{
+			ID:             winner.ID,
+			OrganizationID: org.ID(),
+			OwnerID:        owner,
+			Name:           name,
+			Email:          mergeField(req.Fields["email"], winner.Email, loser.Email),
+			Phone:          mergeField(req.Fields["phone"], winner.Phone, loser.Phone),
+			Company:        mergeField(req.Fields["company"], winner.Company, loser.Company),
+			Position:       mergeField(req.Fields["position"], winner.Position, loser.Position),
+			Notes:          mergeNotes(winner.Notes, loser.Notes),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge contacts")
+			return
+		}
+
+		if err := mergeRelatedRecords(r.Context(), qtx, &merged, loser); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge related records")
+			return
+		}
+
+		// The merged email may belong to another company's domain; explicit
+		// links are left alone
+		if err := autoAssociateCompany(r.Context(), qtx, &merged); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not link company")
+			return
+		}
+
+		_, err = activity.Record(r.Context(), qtx, activity.Entry{
+			OrganizationID: org.ID(),
+			ContactID:      merged.ID,
+			ActorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+			Kind:           activity.KindContactMerged,
+			Summary:        "Merged with " + loser.Name,
+			Details:        map[string]any{"loser_id": loser.ID, "loser_name": loser.Name},
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		err = qtx.DeleteContact(r.Context(), database.DeleteContactParams{
+			ID:             loser.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge contacts")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not merge contacts")
+			return
+		}
+
+		resp := []ContactResponse{NewContactResponse(merged)}
+		if err := attachTags(r.Context(), db, resp); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch contact tags")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp[0])
+	}
+}
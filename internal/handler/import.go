@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+const maxImportFileSize = 10 << 20
+
+var importFields = []string{"name", "email", "phone", "company", "position", "notes"}
+
+// parseImportMapping resolves each contact field to a column index in the CSV
+// header. Custom fields are addressed as "cf.<key>". Without an explicit
+// mapping, headers are matched to field names case-insensitively.
+func parseImportMapping(header []string, raw string, defs []database.CustomField) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	mapping := map[string]string{}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return nil, errors.New("Invalid mapping JSON")
+		}
+	} else {
+		for _, f := range importFields {
+			mapping[f] = f
+		}
+		for _, f := range defs {
+			mapping[customFieldQueryPrefix+f.Key] = f.Name
+		}
+	}
+
+	columns := map[string]int{}
+	for field, column := range mapping {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !isImportField(field, defs) {
+			return nil, errors.New("Unknown contact field in mapping: " + field)
+		}
+		if column == "" {
+			continue
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			if raw == "" {
+				continue
+			}
+			return nil, errors.New("Column not found in CSV header: " + column)
+		}
+		columns[field] = i
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("A column must be mapped to name")
+	}
+	return columns, nil
+}
+
+func isImportField(field string, defs []database.CustomField) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	for _, f := range defs {
+		if customFieldQueryPrefix+f.Key == field {
+			return true
+		}
+	}
+	return false
+}
+
+func importRecordToParams(record []string, columns map[string]int, defs []database.CustomField) (CreateContactRequest, map[int64]*string, error) {
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	req := CreateContactRequest{
+		Name:     value("name"),
+		Email:    value("email"),
+		Phone:    value("phone"),
+		Company:  value("company"),
+		Position: value("position"),
+		Notes:    value("notes"),
+	}
+
+	if req.Name == "" {
+		return req, nil, errors.New("Contact name is required")
+	}
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			return req, nil, errors.New("Invalid email address")
+		}
+	}
+
+	req.CustomFields = map[string]any{}
+	for _, f := range defs {
+		if _, ok := columns[customFieldQueryPrefix+f.Key]; ok {
+			req.CustomFields[f.Key] = value(customFieldQueryPrefix + f.Key)
+		}
+	}
+	customValues, err := resolveCustomValues(defs, req.CustomFields, true)
+	if err != nil {
+		return req, nil, err
+	}
+	return req, customValues, nil
+}
+
+type importRow struct {
+	contact      CreateContactRequest
+	customValues map[int64]*string
+}
+
+func ImportContactsCSVHandler(conn *sql.DB, db *database.Queries, plans *entitlements.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+		if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid multipart form")
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Missing CSV file")
+			return
+		}
+		defer file.Close()
+
+		dryRun := parseBoolQuery(r.FormValue("dry_run"))
+
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		header, err := reader.Read()
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Could not read CSV header")
+			return
+		}
+
+		defs, err := db.GetCustomFieldsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch custom fields")
+			return
+		}
+
+		columns, err := parseImportMapping(header, r.FormValue("mapping"), defs)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		resp := ImportContactsResponse{DryRun: dryRun, Errors: []ImportRowError{}}
+		var valid []importRow
+
+		// Rows are reported by the line they start on, counting the header,
+		// so quoted values spanning several lines don't throw them off
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			resp.TotalRows++
+			if err != nil {
+				row := 0
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					row = parseErr.StartLine
+				}
+				resp.Errors = append(resp.Errors, ImportRowError{Row: row, Error: "Malformed CSV row"})
+				continue
+			}
+			row, _ := reader.FieldPos(0)
+
+			req, customValues, err := importRecordToParams(record, columns, defs)
+			if err != nil {
+				resp.Errors = append(resp.Errors, ImportRowError{Row: row, Error: err.Error()})
+				continue
+			}
+			valid = append(valid, importRow{contact: req, customValues: customValues})
+		}
+		resp.ValidRows = len(valid)
+
+		if dryRun {
+			count, err := ContactUsage(db)(r.Context(), user)
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Failed to fetch count")
+				return
+			}
+			err = plans.CheckLimit(user.Plan, entitlements.LimitContacts, count, int64(len(valid)))
+			resp.LimitExceeded = errors.Is(err, entitlements.ErrLimitReached)
+		}
+
+		if !dryRun && len(valid) > 0 {
+			tx, err := conn.BeginTx(r.Context(), nil)
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not start import")
+				return
+			}
+			defer tx.Rollback()
+
+			qtx := db.WithTx(tx)
+
+			var limitErr *entitlements.LimitError
+			if err := reserveContacts(r.Context(), qtx, plans, user, int64(len(valid))); errors.As(err, &limitErr) {
+				WriteJSONError(w, http.StatusForbidden, "Import would exceed the contact limit. "+limitErr.Message())
+				return
+			} else if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not import contacts")
+				return
+			}
+			for _, row := range valid {
+				req := row.contact
+				contact, err := qtx.CreateContact(r.Context(), database.CreateContactParams{
+					OrganizationID: org.ID(),
+					OwnerID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+					CreatedBy:      uuid.NullUUID{UUID: user.ID, Valid: true},
+					Name:           req.Name,
+					Email:          ToNullString(req.Email),
+					Phone:          ToNullString(req.Phone),
+					Company:        ToNullString(req.Company),
+					Position:       ToNullString(req.Position),
+					Notes:          ToNullString(req.Notes),
+				})
+				if err != nil {
+					WriteJSONError(w, http.StatusInternalServerError, "Could not import contacts")
+					return
+				}
+				if err := saveCustomValues(r.Context(), qtx, contact.ID, row.customValues); err != nil {
+					WriteJSONError(w, http.StatusInternalServerError, "Could not import contacts")
+					return
+				}
+				if err := autoAssociateCompany(r.Context(), qtx, &contact); err != nil {
+					WriteJSONError(w, http.StatusInternalServerError, "Could not import contacts")
+					return
+				}
+				if err := recordContactCreated(r.Context(), qtx, contact, user.ID, "import"); err != nil {
+					WriteJSONError(w, http.StatusInternalServerError, "Could not import contacts")
+					return
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not import contacts")
+				return
+			}
+			resp.Imported = len(valid)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+	"github.com/MudassirDev/mini-hubspot/internal/organization"
+	"github.com/google/uuid"
+)
+
+func newInvitationResponse(inv database.GetPendingOrganizationInvitationsRow) InvitationResponse {
+	return InvitationResponse{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		InvitedBy: inv.InviterName,
+		ExpiresAt: inv.ExpiresAt,
+		CreatedAt: inv.CreatedAt,
+	}
+}
+
+// LoadInvitations lists the organization's invitations that can still be
+// accepted, newest first.
+func LoadInvitations(ctx context.Context, db *database.Queries, orgID uuid.UUID) ([]InvitationResponse, error) {
+	invitations, err := db.GetPendingOrganizationInvitations(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]InvitationResponse, len(invitations))
+	for i, inv := range invitations {
+		resp[i] = newInvitationResponse(inv)
+	}
+	return resp, nil
+}
+
+// invitationProblem reports whether err is about the invitation itself rather
+// than a failure to look it up.
+func invitationProblem(err error) bool {
+	for _, target := range []error{
+		organization.ErrInvitationInvalid,
+		organization.ErrInvitationExpired,
+		organization.ErrInvitationRevoked,
+		organization.ErrInvitationAccepted,
+		organization.ErrInvitationEmail,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// invitationErrorMessage explains why an invitation link can't be used.
+func invitationErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, organization.ErrInvitationExpired):
+		return "This invitation has expired. Ask for a new one."
+	case errors.Is(err, organization.ErrInvitationRevoked):
+		return "This invitation has been revoked."
+	case errors.Is(err, organization.ErrInvitationAccepted):
+		return "This invitation has already been accepted."
+	case errors.Is(err, organization.ErrInvitationEmail):
+		return "This invitation was sent to a different email address."
+	case errors.Is(err, organization.ErrInvitationInvalid):
+		return "This invitation link is invalid."
+	default:
+		return "Could not accept the invitation"
+	}
+}
+
+func writeInvitationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, organization.ErrInvitationExpired),
+		errors.Is(err, organization.ErrInvitationRevoked),
+		errors.Is(err, organization.ErrInvitationAccepted):
+		WriteJSONError(w, http.StatusGone, invitationErrorMessage(err))
+	case errors.Is(err, organization.ErrInvitationEmail):
+		WriteJSONError(w, http.StatusForbidden, invitationErrorMessage(err))
+	case errors.Is(err, organization.ErrInvitationInvalid):
+		WriteJSONError(w, http.StatusBadRequest, invitationErrorMessage(err))
+	default:
+		log.Printf("Failed to accept invitation: %v", err)
+		WriteJSONError(w, http.StatusInternalServerError, invitationErrorMessage(err))
+	}
+}
+
+// acceptInvitation joins the user to the organization from a signed
+// invitation token.
+func acceptInvitation(ctx context.Context, conn *sql.DB, db *database.Queries, secret, token string, user database.User) (*organization.Membership, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	m, err := organization.Accept(ctx, db.WithTx(tx), secret, token, user)
+	if err != nil {
+		return nil, err
+	}
+	return m, tx.Commit()
+}
+
+// acceptInvitationOnLogin accepts the invitation a user followed to the login
+// page. The login succeeds either way; a bad invitation is reported in the
+// response.
+func acceptInvitationOnLogin(ctx context.Context, conn *sql.DB, db *database.Queries, secret, token string, user database.User, resp *LoginResponse) {
+	if token == "" {
+		return
+	}
+
+	m, err := acceptInvitation(ctx, conn, db, secret, token, user)
+	if err != nil {
+		if !invitationProblem(err) {
+			log.Printf("Failed to accept invitation for %s on login: %v", user.Email, err)
+		}
+		resp.InviteError = invitationErrorMessage(err)
+		return
+	}
+
+	resp.Organization = &OrganizationResponse{
+		ID:        m.ID(),
+		Name:      m.Organization.Name,
+		Role:      m.Role,
+		CreatedAt: m.Organization.CreatedAt,
+		Current:   true,
+	}
+}
+
+// InvitationPage is what the accept page knows about an invitation link.
+type InvitationPage struct {
+	Token            string
+	OrganizationName string
+	InviterName      string
+	Email            string
+	Role             string
+	Error            string
+}
+
+// LoadInvitationPage looks up the invitation behind a link for the accept
+// page.
+func LoadInvitationPage(ctx context.Context, db *database.Queries, secret, token string) InvitationPage {
+	page := InvitationPage{Token: token}
+
+	inv, err := organization.FindInvitation(ctx, db, secret, token)
+	if err != nil {
+		if !invitationProblem(err) {
+			log.Printf("Failed to look up invitation: %v", err)
+		}
+		page.Error = invitationErrorMessage(err)
+		return page
+	}
+
+	page.OrganizationName = inv.OrganizationName
+	page.InviterName = inv.InviterName
+	page.Email = inv.Email
+	page.Role = inv.Role
+	return page
+}
+
+// CreateInvitationHandler invites someone by email to the current
+// organization. Admins only. The email is queued in the same transaction as
+// the invitation.
+func CreateInvitationHandler(conn *sql.DB, db *database.Queries, mailer *email.Mailer, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req InvitationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "A valid email is required")
+			return
+		}
+		if req.Role == "" {
+			req.Role = "user"
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create invitation")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		inv, err := organization.Invite(r.Context(), qtx, org.ID(), user.ID, req.Email, req.Role)
+		if err != nil {
+			switch {
+			case errors.Is(err, organization.ErrInvalidRole):
+				WriteJSONError(w, http.StatusBadRequest, "Role must be user or admin")
+			case errors.Is(err, organization.ErrAlreadyMember):
+				WriteJSONError(w, http.StatusConflict, "That person is already a member of this organization")
+			default:
+				log.Printf("Failed to create invitation: %v", err)
+				WriteJSONError(w, http.StatusInternalServerError, "Could not create invitation")
+			}
+			return
+		}
+
+		token, err := organization.InvitationToken(inv, secret)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create invitation")
+			return
+		}
+
+		inviteLink := fmt.Sprintf("%s/invitations/accept?token=%s", os.Getenv("APP_HOST"), url.QueryEscape(token))
+		err = mailer.Outbox(r.Context(), qtx).SendInvitationEmail(inv.Email, user.FirstName, org.Organization.Name, inv.Role, inviteLink, organization.InvitationExpiry)
+		if err != nil {
+			log.Println("failed to queue invitation email:", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create invitation")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create invitation")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(InvitationResponse{
+			ID:        inv.ID,
+			Email:     inv.Email,
+			Role:      inv.Role,
+			InvitedBy: user.FirstName,
+			ExpiresAt: inv.ExpiresAt,
+			CreatedAt: inv.CreatedAt,
+		})
+	}
+}
+
+// GetInvitationsHandler lists the current organization's pending
+// invitations. Admins only.
+func GetInvitationsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		invitations, err := LoadInvitations(r.Context(), db, org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch invitations")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invitations)
+	}
+}
+
+// RevokeInvitationHandler disables a pending invitation's link. Admins only.
+func RevokeInvitationHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid invitation ID")
+			return
+		}
+
+		revoked, err := db.RevokeOrganizationInvitation(r.Context(), database.RevokeOrganizationInvitationParams{
+			ID:             id,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not revoke invitation")
+			return
+		}
+		if revoked == 0 {
+			WriteJSONError(w, http.StatusNotFound, "Invitation not found")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AcceptInvitationHandler joins the logged-in user to the organization they
+// were invited to.
+func AcceptInvitationHandler(conn *sql.DB, db *database.Queries, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req AcceptInvitationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		m, err := acceptInvitation(r.Context(), conn, db, secret, req.Token, *user)
+		if err != nil {
+			writeInvitationError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OrganizationResponse{
+			ID:        m.ID(),
+			Name:      m.Organization.Name,
+			Role:      m.Role,
+			CreatedAt: m.Organization.CreatedAt,
+			Current:   true,
+		})
+	}
+}
@@ -12,6 +12,9 @@ type CreateUserRequest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Password  string `json:"password"`
+
+	// InviteToken accepts an organization invitation with the new account
+	InviteToken string `json:"invite_token,omitempty"`
 }
 
 type CreateUserResponse struct {
@@ -22,8 +25,9 @@ type CreateUserResponse struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	InviteToken string `json:"invite_token,omitempty"`
 }
 
 type LoginResponse struct {
@@ -31,35 +35,399 @@ type LoginResponse struct {
 	Email string `json:"email"`
 	Plan  string `json:"plan"`
 	Role  string `json:"role"`
+
+	// Set when the login accepted an invitation
+	Organization *OrganizationResponse `json:"organization,omitempty"`
+	InviteError  string                `json:"invite_error,omitempty"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code        string `json:"code"`
+	InviteToken string `json:"invite_token,omitempty"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type UpdateSettingsRequest struct {
+	RequireTwoFactor *bool `json:"require_two_factor"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
 }
 
 type CreateContactRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email,omitempty"`
-	Phone    string `json:"phone,omitempty"`
-	Company  string `json:"company,omitempty"`
-	Position string `json:"position,omitempty"`
-	Notes    string `json:"notes,omitempty"`
+	Name         string         `json:"name"`
+	Email        string         `json:"email,omitempty"`
+	Phone        string         `json:"phone,omitempty"`
+	Company      string         `json:"company,omitempty"`
+	Position     string         `json:"position,omitempty"`
+	Notes        string         `json:"notes,omitempty"`
+	CompanyID    *int64         `json:"company_id,omitempty"`
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	// OwnerID defaults to the user creating the contact
+	OwnerID *string `json:"owner_id,omitempty"`
 }
 
 type ContactResponse struct {
+	Name           string         `json:"name"`
+	Email          string         `json:"email"`
+	Phone          string         `json:"phone"`
+	Company        string         `json:"company"`
+	Position       string         `json:"position"`
+	Notes          string         `json:"notes"`
+	CompanyID      *int64         `json:"company_id,omitempty"`
+	Tags           []string       `json:"tags,omitempty"`
+	CustomFields   map[string]any `json:"custom_fields,omitempty"`
+	OwnerID        string         `json:"owner_id,omitempty"`
+	OrganizationID uuid.UUID      `json:"organization_id"`
+	ID             int64          `json:"contact_id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty"`
+}
+
+type PatchContactRequest struct {
+	Name         *string        `json:"name,omitempty"`
+	Email        *string        `json:"email,omitempty"`
+	Phone        *string        `json:"phone,omitempty"`
+	Company      *string        `json:"company,omitempty"`
+	Position     *string        `json:"position,omitempty"`
+	Notes        *string        `json:"notes,omitempty"`
+	CompanyID    *int64         `json:"company_id,omitempty"`
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	// OwnerID set to "" leaves the contact without an owner
+	OwnerID *string `json:"owner_id,omitempty"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportContactsResponse struct {
+	DryRun        bool             `json:"dry_run"`
+	TotalRows     int              `json:"total_rows"`
+	ValidRows     int              `json:"valid_rows"`
+	Imported      int              `json:"imported"`
+	LimitExceeded bool             `json:"limit_exceeded"`
+	Errors        []ImportRowError `json:"errors"`
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type CreateCustomFieldRequest struct {
+	Name     string   `json:"name"`
+	Key      string   `json:"key,omitempty"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Position int32    `json:"position,omitempty"`
+}
+
+type PatchCustomFieldRequest struct {
+	Name     *string  `json:"name,omitempty"`
+	Options  []string `json:"options,omitempty"`
+	Required *bool    `json:"required,omitempty"`
+	Position *int32   `json:"position,omitempty"`
+}
+
+type CustomFieldResponse struct {
+	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Phone     string    `json:"phone"`
-	Company   string    `json:"company"`
-	Position  string    `json:"position"`
-	Notes     string    `json:"notes"`
-	UserID    uuid.UUID `json:"user_id"`
-	ID        int64     `json:"contact_id"`
+	Key       string    `json:"key"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options"`
+	Required  bool      `json:"required"`
+	Position  int32     `json:"position"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-type PatchContactRequest struct {
+type DuplicateGroupResponse struct {
+	Reason   string            `json:"reason"`
+	Key      string            `json:"key"`
+	Contacts []ContactResponse `json:"contacts"`
+}
+
+type MergeContactsRequest struct {
+	WinnerID int64             `json:"winner_id"`
+	LoserID  int64             `json:"loser_id"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+type CreateCompanyRequest struct {
+	Name     string `json:"name"`
+	Domain   string `json:"domain,omitempty"`
+	Industry string `json:"industry,omitempty"`
+	Size     string `json:"size,omitempty"`
+	Address  string `json:"address,omitempty"`
+}
+
+type PatchCompanyRequest struct {
 	Name     *string `json:"name,omitempty"`
-	Email    *string `json:"email,omitempty"`
-	Phone    *string `json:"phone,omitempty"`
-	Company  *string `json:"company,omitempty"`
-	Position *string `json:"position,omitempty"`
-	Notes    *string `json:"notes,omitempty"`
+	Domain   *string `json:"domain,omitempty"`
+	Industry *string `json:"industry,omitempty"`
+	Size     *string `json:"size,omitempty"`
+	Address  *string `json:"address,omitempty"`
+}
+
+type CompanyResponse struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Domain         string    `json:"domain"`
+	Industry       string    `json:"industry"`
+	Size           string    `json:"size"`
+	Address        string    `json:"address"`
+	LinkedContacts int64     `json:"linked_contacts,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CreatePipelineRequest struct {
+	Name   string   `json:"name"`
+	Stages []string `json:"stages,omitempty"`
+}
+
+type StageRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Position *int32  `json:"position,omitempty"`
+}
+
+type StageResponse struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Position int32  `json:"position"`
+}
+
+type PipelineResponse struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Stages    []StageResponse `json:"stages"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type CreateDealRequest struct {
+	StageID    int64   `json:"stage_id"`
+	Title      string  `json:"title"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency,omitempty"`
+	CloseDate  string  `json:"close_date,omitempty"`
+	ContactIDs []int64 `json:"contact_ids,omitempty"`
+	// OwnerID defaults to the user creating the deal
+	OwnerID *string `json:"owner_id,omitempty"`
+}
+
+type PatchDealRequest struct {
+	StageID   *int64   `json:"stage_id,omitempty"`
+	Title     *string  `json:"title,omitempty"`
+	Amount    *float64 `json:"amount,omitempty"`
+	Currency  *string  `json:"currency,omitempty"`
+	CloseDate *string  `json:"close_date,omitempty"`
+	// OwnerID set to "" leaves the deal without an owner
+	OwnerID *string `json:"owner_id,omitempty"`
+}
+
+type DealContactRequest struct {
+	ContactID int64 `json:"contact_id"`
+}
+
+type StageChangeResponse struct {
+	FromStage string    `json:"from_stage,omitempty"`
+	ToStage   string    `json:"to_stage,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type DealResponse struct {
+	ID         int64                 `json:"id"`
+	PipelineID int64                 `json:"pipeline_id"`
+	StageID    int64                 `json:"stage_id"`
+	Title      string                `json:"title"`
+	Amount     float64               `json:"amount"`
+	Currency   string                `json:"currency"`
+	CloseDate  string                `json:"close_date,omitempty"`
+	OwnerID    string                `json:"owner_id,omitempty"`
+	Contacts   []ContactResponse     `json:"contacts,omitempty"`
+	History    []StageChangeResponse `json:"history,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+}
+
+type CreateTaskRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	DueAt       string  `json:"due_at,omitempty"`
+	Priority    string  `json:"priority,omitempty"`
+	Status      string  `json:"status,omitempty"`
+	AssigneeID  *string `json:"assignee_id,omitempty"`
+}
+
+type PatchTaskRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	DueAt       *string `json:"due_at,omitempty"`
+	Priority    *string `json:"priority,omitempty"`
+	Status      *string `json:"status,omitempty"`
+	AssigneeID  *string `json:"assignee_id,omitempty"`
+}
+
+type TaskResponse struct {
+	ID          int64      `json:"id"`
+	ContactID   int64      `json:"contact_id"`
+	ContactName string     `json:"contact_name,omitempty"`
+	AssigneeID  string     `json:"assignee_id,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Priority    string     `json:"priority"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CreateActivityRequest struct {
+	Kind    string `json:"kind,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+type ActivityResponse struct {
+	ID        int64          `json:"id"`
+	Kind      string         `json:"kind"`
+	Summary   string         `json:"summary"`
+	Details   map[string]any `json:"details"`
+	ActorID   string         `json:"actor_id,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type SwitchOrganizationRequest struct {
+	OrganizationID string `json:"organization_id"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type OrganizationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+type CheckoutResponse struct {
+	URL string `json:"url"`
+}
+
+type TrialResponse struct {
+	Plan        string    `json:"plan"`
+	TrialEndsAt time.Time `json:"trial_ends_at"`
+}
+
+type InvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+type InvitationResponse struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invited_by,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type OutboxEmailResponse struct {
+	ID            int64      `json:"id"`
+	To            string     `json:"to"`
+	Subject       string     `json:"subject"`
+	Category      string     `json:"category"`
+	Status        string     `json:"status"`
+	Attempts      int32      `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type EmailOutboxStatus struct {
+	Pending int64                 `json:"pending"`
+	Sent    int64                 `json:"sent"`
+	Dead    int64                 `json:"dead"`
+	Failing []OutboxEmailResponse `json:"failing"`
+}
+
+type StripeEventResponse struct {
+	ID            string     `json:"id"`
+	Type          string     `json:"type"`
+	Status        string     `json:"status"`
+	Attempts      int32      `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReceivedAt    time.Time  `json:"received_at"`
+}
+
+type StripeEventsStatus struct {
+	Pending   int64                 `json:"pending"`
+	Processed int64                 `json:"processed"`
+	Failed    int64                 `json:"failed"`
+	Failing   []StripeEventResponse `json:"failing"`
 }
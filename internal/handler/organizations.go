@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+	"github.com/MudassirDev/mini-hubspot/internal/organization"
+	"github.com/google/uuid"
+)
+
+const maxOrganizationNameLength = 100
+
+// currentMember returns the logged-in user and the organization they are
+// working in. Contacts and everything attached to them are scoped by the
+// organization; the user is the actor.
+func currentMember(ctx context.Context) (*database.User, *organization.Membership, bool) {
+	user, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+	org, ok := middleware.GetOrganizationFromContext(ctx)
+	return user, org, ok
+}
+
+// parseMemberID resolves a user ID sent by the client, such as a contact
+// owner or task assignee, to a member of the organization. Empty means
+// nobody. IDs of non-members return organization.ErrNotAMember.
+func parseMemberID(ctx context.Context, db *database.Queries, orgID uuid.UUID, s string) (uuid.NullUUID, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}, organization.ErrNotAMember
+	}
+	member, err := organization.IsMember(ctx, db, orgID, id)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	if !member {
+		return uuid.NullUUID{}, organization.ErrNotAMember
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+// writeMemberIDError reports a parseMemberID failure for the named field.
+func writeMemberIDError(w http.ResponseWriter, field string, err error) {
+	if errors.Is(err, organization.ErrNotAMember) {
+		WriteJSONError(w, http.StatusBadRequest, "The "+field+" must be a member of this organization")
+		return
+	}
+	WriteJSONError(w, http.StatusInternalServerError, "Could not check organization members")
+}
+
+func normalizeOrganizationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("Organization name is required")
+	}
+	if len(name) > maxOrganizationNameLength {
+		return "", errors.New("Organization name is too long")
+	}
+	return name, nil
+}
+
+// LoadOrganizations lists the organizations the user belongs to, marking the
+// one they are working in.
+func LoadOrganizations(ctx context.Context, db *database.Queries, userID, currentID uuid.UUID) ([]OrganizationResponse, error) {
+	orgs, err := db.GetOrganizationsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]OrganizationResponse, len(orgs))
+	for i, o := range orgs {
+		resp[i] = OrganizationResponse{
+			ID:        o.ID,
+			Name:      o.Name,
+			Role:      o.Role,
+			CreatedAt: o.CreatedAt,
+			Current:   o.ID == currentID,
+		}
+	}
+	return resp, nil
+}
+
+// LoadOrganizationMembers lists everyone in the organization, oldest member
+// first.
+func LoadOrganizationMembers(ctx context.Context, db *database.Queries, orgID uuid.UUID) ([]OrganizationMemberResponse, error) {
+	members, err := db.GetOrganizationMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]OrganizationMemberResponse, len(members))
+	for i, m := range members {
+		resp[i] = OrganizationMemberResponse{
+			UserID:    m.ID,
+			Username:  m.Username,
+			Email:     m.Email,
+			FirstName: m.FirstName,
+			LastName:  m.LastName,
+			Role:      m.Role,
+			JoinedAt:  m.JoinedAt,
+		}
+	}
+	return resp, nil
+}
+
+// GetOrganizationsHandler returns the user's organizations and the members of
+// the current one.
+func GetOrganizationsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		orgs, err := LoadOrganizations(r.Context(), db, user.ID, org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch organizations")
+			return
+		}
+
+		members, err := LoadOrganizationMembers(r.Context(), db, org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch members")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"organizations": orgs,
+			"members":       members,
+		})
+	}
+}
+
+// CreateOrganizationHandler starts a new organization with the user as its
+// admin and switches them to it.
+func CreateOrganizationHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req OrganizationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name, err := normalizeOrganizationName(req.Name)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create organization")
+			return
+		}
+		defer tx.Rollback()
+
+		org, err := organization.Create(r.Context(), db.WithTx(tx), name, user.ID)
+		if err != nil {
+			log.Printf("Failed to create organization: %v", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create organization")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create organization")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(OrganizationResponse{
+			ID:        org.ID,
+			Name:      org.Name,
+			Role:      auth.RoleAdmin,
+			CreatedAt: org.CreatedAt,
+			Current:   true,
+		})
+	}
+}
+
+// SwitchOrganizationHandler changes the organization the user is working in.
+func SwitchOrganizationHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req SwitchOrganizationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		orgID, err := uuid.Parse(req.OrganizationID)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid organization ID")
+			return
+		}
+
+		m, err := organization.Get(r.Context(), db, orgID, user.ID)
+		if err != nil {
+			if errors.Is(err, organization.ErrNotAMember) {
+				WriteJSONError(w, http.StatusNotFound, "Organization not found")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not switch organization")
+			return
+		}
+
+		err = db.SetCurrentOrganization(r.Context(), database.SetCurrentOrganizationParams{
+			ID:                    user.ID,
+			CurrentOrganizationID: uuid.NullUUID{UUID: m.ID(), Valid: true},
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not switch organization")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OrganizationResponse{
+			ID:        m.ID(),
+			Name:      m.Organization.Name,
+			Role:      m.Role,
+			CreatedAt: m.Organization.CreatedAt,
+			Current:   true,
+		})
+	}
+}
+
+// RenameOrganizationHandler renames the current organization. Admins only.
+func RenameOrganizationHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req OrganizationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name, err := normalizeOrganizationName(req.Name)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		renamed, err := db.RenameOrganization(r.Context(), database.RenameOrganizationParams{
+			ID:   org.ID(),
+			Name: name,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not rename organization")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OrganizationResponse{
+			ID:        renamed.ID,
+			Name:      renamed.Name,
+			Role:      org.Role,
+			CreatedAt: renamed.CreatedAt,
+			Current:   true,
+		})
+	}
+}
+
+func memberIDFromRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid member ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// writeMembershipError reports errors from changing a member of the
+// organization.
+func writeMembershipError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, organization.ErrNotAMember):
+		WriteJSONError(w, http.StatusNotFound, "Member not found")
+	case errors.Is(err, organization.ErrLastAdmin):
+		WriteJSONError(w, http.StatusConflict, "The organization needs at least one admin. Make someone else an admin first.")
+	case errors.Is(err, organization.ErrInvalidRole):
+		WriteJSONError(w, http.StatusBadRequest, "Role must be user or admin")
+	default:
+		log.Printf("%s: %v", fallback, err)
+		WriteJSONError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// UpdateMemberRoleHandler makes a member a user or an admin of the current
+// organization. Admins only.
+func UpdateMemberRoleHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		memberID, ok := memberIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		var req MemberRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update member")
+			return
+		}
+		defer tx.Rollback()
+
+		if err := organization.SetRole(r.Context(), db.WithTx(tx), org.ID(), memberID, req.Role); err != nil {
+			writeMembershipError(w, err, "Could not update member")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update member")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveMemberHandler takes a member out of the current organization. Admins
+// can remove anyone; other members can only remove themselves to leave.
+func RemoveMemberHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		memberID, ok := memberIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		if memberID != user.ID && !org.IsAdmin() {
+			WriteJSONError(w, http.StatusForbidden, "Only admins can remove other members")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not remove member")
+			return
+		}
+		defer tx.Rollback()
+
+		if err := organization.RemoveMember(r.Context(), db.WithTx(tx), org.ID(), memberID); err != nil {
+			writeMembershipError(w, err, "Could not remove member")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not remove member")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
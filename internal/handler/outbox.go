@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+)
+
+// failingEmailsLimit caps how many failing emails the admin page lists
+const failingEmailsLimit = 50
+
+// LoadEmailOutbox counts outbox emails by status and lists the ones that are
+// being retried or have been dead-lettered.
+func LoadEmailOutbox(ctx context.Context, db *database.Queries) (EmailOutboxStatus, error) {
+	var status EmailOutboxStatus
+
+	counts, err := db.CountEmailsByStatus(ctx)
+	if err != nil {
+		return status, err
+	}
+	for _, c := range counts {
+		switch c.Status {
+		case email.OutboxPending:
+			status.Pending = c.Count
+		case email.OutboxSent:
+			status.Sent = c.Count
+		case email.OutboxDead:
+			status.Dead = c.Count
+		}
+	}
+
+	failing, err := db.GetFailingEmails(ctx, failingEmailsLimit)
+	if err != nil {
+		return status, err
+	}
+	status.Failing = make([]OutboxEmailResponse, len(failing))
+	for i, e := range failing {
+		status.Failing[i] = OutboxEmailResponse{
+			ID:        e.ID,
+			To:        e.ToEmail,
+			Subject:   e.Subject,
+			Category:  e.Category,
+			Status:    e.Status,
+			Attempts:  e.Attempts,
+			LastError: e.LastError.String,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.Status == email.OutboxPending {
+			status.Failing[i].NextAttemptAt = &e.NextAttemptAt
+		}
+	}
+	return status, nil
+}
+
+func GetEmailOutboxHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, err := LoadEmailOutbox(r.Context(), db)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not load email outbox")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// RetryEmailHandler puts a dead-lettered email back in the queue with a fresh
+// set of attempts.
+func RetryEmailHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		emailID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid email ID")
+			return
+		}
+
+		requeued, err := db.RequeueDeadEmail(r.Context(), emailID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not retry email")
+			return
+		}
+		if requeued == 0 {
+			WriteJSONError(w, http.StatusNotFound, "No dead-lettered email with that ID")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
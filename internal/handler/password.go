@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/session"
+)
+
+const PasswordResetExpiry = 1 * time.Hour
+
+// forgotPasswordMessage is returned whether or not the email has an account,
+// so the endpoint can't be used to find out who is signed up.
+const forgotPasswordMessage = "If an account exists for that email, a reset link is on its way"
+
+// ForgotPasswordHandler emails a single-use reset link. Requesting a new link
+// invalidates any earlier ones. Requests are throttled per address and per
+// client; throttled ones get the usual response but no email.
+func ForgotPasswordHandler(conn *sql.DB, db *database.Queries, mailer *email.Mailer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+		if req.Email == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Email is required")
+			return
+		}
+
+		allowed, err := allowPasswordReset(r.Context(), db, r, req.Email)
+		if err != nil {
+			log.Printf("DB error during password reset request: %v", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if !allowed {
+			json.NewEncoder(w).Encode(map[string]string{"message": forgotPasswordMessage})
+			return
+		}
+
+		user, err := db.GetUserByEmail(r.Context(), req.Email)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("DB error during password reset request: %v", err)
+				WriteJSONError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"message": forgotPasswordMessage})
+			return
+		}
+
+		token, err := auth.GenerateVerificationToken()
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create reset token")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		if err := qtx.InvalidatePasswordResetTokens(r.Context(), user.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create reset token")
+			return
+		}
+		err = qtx.CreatePasswordResetToken(r.Context(), database.CreatePasswordResetTokenParams{
+			UserID:    user.ID,
+			TokenHash: auth.HashToken(token),
+			ExpiresAt: time.Now().Add(PasswordResetExpiry),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create reset token")
+			return
+		}
+
+		resetLink := fmt.Sprintf("%s/reset-password?token=%s", os.Getenv("APP_HOST"), token)
+
+		err = mailer.Outbox(r.Context(), qtx).SendPasswordResetEmail(user.Email, user.FirstName, resetLink, PasswordResetExpiry)
+		if err != nil {
+			log.Println("failed to queue password reset email:", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not send reset email")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create reset token")
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": forgotPasswordMessage})
+	}
+}
+
+// ResetPasswordHandler sets a new password from a reset token and revokes
+// every existing session of the account.
+func ResetPasswordHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		if req.Token == "" || req.Password == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Token and password are required")
+			return
+		}
+		if err := auth.CheckPassword(req.Password); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		hashedPassword, err := auth.HashPassword(req.Password)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to hash password")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not reset password")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		reset, err := qtx.ConsumePasswordResetToken(r.Context(), auth.HashToken(req.Token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				WriteJSONError(w, http.StatusBadRequest, "Invalid or expired token")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not reset password")
+			return
+		}
+
+		err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			ID:           reset.UserID,
+			PasswordHash: hashedPassword,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not reset password")
+			return
+		}
+
+		if err := qtx.InvalidatePasswordResetTokens(r.Context(), reset.UserID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not reset password")
+			return
+		}
+
+		if err := qtx.RevokeUserSessions(r.Context(), reset.UserID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not reset password")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not reset password")
+			return
+		}
+
+		// The browser may still hold cookies for a session that was just revoked
+		session.ClearCookies(w)
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Password updated. Please log in again."})
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// LoadSessions lists the user's signed-in devices, marking the one making the
+// request.
+func LoadSessions(ctx context.Context, db *database.Queries, userID, currentID uuid.UUID) ([]SessionResponse, error) {
+	sessions, err := db.GetActiveSessionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]SessionResponse, len(sessions))
+	for i, s := range sessions {
+		resp[i] = SessionResponse{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IpAddress,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			Current:    s.ID == currentID,
+		}
+	}
+	return resp, nil
+}
+
+func GetSessionsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		currentID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+		sessions, err := LoadSessions(r.Context(), db, user.ID, currentID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch sessions")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"sessions": sessions})
+	}
+}
+
+// RevokeSessionHandler signs a device out. Its access token stops working on
+// the next request and its refresh token can't be used again.
+func RevokeSessionHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		sessionID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid session ID")
+			return
+		}
+
+		revoked, err := db.RevokeSession(r.Context(), database.RevokeSessionParams{
+			ID:     sessionID,
+			UserID: user.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not revoke session")
+			return
+		}
+		if revoked == 0 {
+			WriteJSONError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
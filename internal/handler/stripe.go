@@ -1,18 +1,25 @@
 package handler
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
 	"os"
 
+	"github.com/MudassirDev/mini-hubspot/internal/billing"
 	"github.com/MudassirDev/mini-hubspot/internal/database"
-	"github.com/stripe/stripe-go/v82"
 	"github.com/stripe/stripe-go/v82/webhook"
 )
 
+// failingStripeEventsLimit caps how many failing events the admin page lists
+const failingStripeEventsLimit = 50
+
+// StripeWebhookHandler stores verified Stripe events for the worker to
+// process. Stripe gets a 200 only once the event is stored, so a failed write
+// is retried by Stripe, and a redelivered event is acknowledged without being
+// stored again.
 func StripeWebhookHandler(db *database.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		stripeWebhookSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")
@@ -32,69 +39,89 @@ func StripeWebhookHandler(db *database.Queries) http.HandlerFunc {
 			return
 		}
 
-		switch event.Type {
-		case "checkout.session.completed":
-			var session stripe.CheckoutSession
-			if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
-				http.Error(w, "Unmarshal error", http.StatusBadRequest)
-				return
-			}
-
-			email := session.CustomerDetails.Email
-			customerID := session.Customer.ID
-
-			log.Printf("Checkout completed for %s (Stripe ID: %s)", email, customerID)
-
-			// Store Stripe customer ID now
-			err := db.UpdateStripeCustomerIDByEmail(r.Context(), database.UpdateStripeCustomerIDByEmailParams{
-				Email: email,
-				StripeCustomerID: sql.NullString{
-					String: customerID,
-					Valid:  true,
-				},
-			})
-			if err != nil {
-				log.Printf("Failed to save customer ID for %s: %v", email, err)
-			}
+		stored, err := billing.RecordEvent(r.Context(), db, event, payload)
+		if err != nil {
+			log.Printf("Failed to store Stripe event %s: %v", event.ID, err)
+			http.Error(w, "Could not store event", http.StatusInternalServerError)
+			return
+		}
+		if !stored {
+			log.Printf("Stripe event %s was already received", event.ID)
+		}
 
-		case "invoice.paid":
-			var invoice stripe.Invoice
-			if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
-				http.Error(w, "Unmarshal error", http.StatusBadRequest)
-				return
-			}
+		w.WriteHeader(http.StatusOK)
+	}
+}
 
-			email := invoice.CustomerEmail
-			log.Printf("Invoice paid for: %s", email)
+// LoadStripeEvents counts received Stripe events by status and lists the ones
+// that are being retried or have failed.
+func LoadStripeEvents(ctx context.Context, db *database.Queries) (StripeEventsStatus, error) {
+	var status StripeEventsStatus
 
-			err := db.UpgradeUserPlanByEmail(r.Context(), email)
-			if err != nil {
-				log.Printf("Failed to upgrade plan for %s: %v", email, err)
-			} else {
-				log.Printf("Plan upgraded to 'pro' for %s", email)
-			}
+	counts, err := db.CountStripeEventsByStatus(ctx)
+	if err != nil {
+		return status, err
+	}
+	for _, c := range counts {
+		switch c.Status {
+		case billing.EventPending:
+			status.Pending = c.Count
+		case billing.EventProcessed:
+			status.Processed = c.Count
+		case billing.EventFailed:
+			status.Failed = c.Count
+		}
+	}
 
-		case "customer.subscription.deleted":
-			var sub stripe.Subscription
-			if err := json.Unmarshal(event.Data.Raw, &sub); err != nil {
-				http.Error(w, "Unmarshal error", http.StatusBadRequest)
-				return
-			}
+	failing, err := db.GetFailingStripeEvents(ctx, failingStripeEventsLimit)
+	if err != nil {
+		return status, err
+	}
+	status.Failing = make([]StripeEventResponse, len(failing))
+	for i, e := range failing {
+		status.Failing[i] = StripeEventResponse{
+			ID:         e.ID,
+			Type:       e.Type,
+			Status:     e.Status,
+			Attempts:   e.Attempts,
+			LastError:  e.LastError.String,
+			CreatedAt:  e.StripeCreatedAt,
+			ReceivedAt: e.ReceivedAt,
+		}
+		if e.Status == billing.EventPending {
+			status.Failing[i].NextAttemptAt = &e.NextAttemptAt
+		}
+	}
+	return status, nil
+}
 
-			customerID := sub.Customer.ID
-			log.Printf("Subscription canceled for customer ID: %s", customerID)
+func GetStripeEventsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, err := LoadStripeEvents(r.Context(), db)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not load Stripe events")
+			return
+		}
 
-			err := db.DowngradeUserPlanByStripeCustomerID(r.Context(), sql.NullString{String: customerID, Valid: true})
-			if err != nil {
-				log.Printf("Failed to downgrade plan for customer %s: %v", customerID, err)
-			} else {
-				log.Printf("Plan downgraded to 'free' for customer %s", customerID)
-			}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
 
-		default:
-			log.Printf("Unhandled event type: %s", event.Type)
+// RetryStripeEventHandler puts a failed Stripe event back in the queue with a
+// fresh set of attempts.
+func RetryStripeEventHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requeued, err := db.RequeueFailedStripeEvent(r.Context(), r.PathValue("id"))
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not retry event")
+			return
+		}
+		if requeued == 0 {
+			WriteJSONError(w, http.StatusNotFound, "No failed Stripe event with that ID")
+			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusNoContent)
 	}
 }
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxTagLength = 50
+
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func tagNames(tags []database.Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// attachTags fills in the Tags field of each contact with a single query.
+func attachTags(ctx context.Context, db *database.Queries, contacts []ContactResponse) error {
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(contacts))
+	byID := make(map[int64]*ContactResponse, len(contacts))
+	for i := range contacts {
+		ids[i] = contacts[i].ID
+		byID[contacts[i].ID] = &contacts[i]
+	}
+
+	rows, err := db.GetTagNamesByContactIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if c, ok := byID[row.ContactID]; ok {
+			c.Tags = append(c.Tags, row.Name)
+		}
+	}
+	return nil
+}
+
+// recordTagActivity puts a tag being added to or removed from a contact on its
+// timeline.
+func recordTagActivity(ctx context.Context, qtx *database.Queries, contact database.Contact, actor uuid.UUID, kind, name string) error {
+	summary := "Tagged " + name
+	if kind == activity.KindTagRemoved {
+		summary = "Removed tag " + name
+	}
+	_, err := activity.Record(ctx, qtx, activity.Entry{
+		OrganizationID: contact.OrganizationID,
+		ContactID:      contact.ID,
+		ActorID:        uuid.NullUUID{UUID: actor, Valid: true},
+		Kind:           kind,
+		Summary:        summary,
+		Details:        map[string]any{"tag": name},
+	})
+	return err
+}
+
+func writeContactTags(w http.ResponseWriter, r *http.Request, db *database.Queries, contactID int64) {
+	tags, err := db.GetTagsByContact(r.Context(), contactID)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch tags")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"tags": tagNames(tags)})
+}
+
+func GetTagsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		tags, err := db.GetTagsByOrganization(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch tags")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"tags": tagNames(tags)})
+	}
+}
+
+func GetContactTagsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		writeContactTags(w, r, db, contact.ID)
+	}
+}
+
+func AddContactTagHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req TagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		name := normalizeTagName(req.Name)
+		if name == "" || len(name) > maxTagLength || strings.Contains(name, ",") {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid tag name")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not tag contact")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		tag, err := qtx.UpsertTag(r.Context(), database.UpsertTagParams{
+			OrganizationID: org.ID(),
+			Name:           name,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create tag")
+			return
+		}
+
+		added, err := qtx.AddTagToContact(r.Context(), database.AddTagToContactParams{
+			ContactID: contact.ID,
+			TagID:     tag.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not tag contact")
+			return
+		}
+
+		// Adding a tag the contact already has stays off the timeline
+		if added > 0 {
+			if err := recordTagActivity(r.Context(), qtx, contact, user.ID, activity.KindTagAdded, name); err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not tag contact")
+			return
+		}
+
+		writeContactTags(w, r, db, contact.ID)
+	}
+}
+
+func SetContactTagsHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req SetTagsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		names := make([]string, 0, len(req.Tags))
+		for _, raw := range req.Tags {
+			name := normalizeTagName(raw)
+			if name == "" || len(name) > maxTagLength || strings.Contains(name, ",") {
+				WriteJSONError(w, http.StatusBadRequest, "Invalid tag name: "+raw)
+				return
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update tags")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		existing, err := qtx.GetTagsByContact(r.Context(), contact.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch tags")
+			return
+		}
+		oldNames := tagNames(existing)
+
+		if err := qtx.ClearContactTags(r.Context(), contact.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update tags")
+			return
+		}
+		for _, name := range names {
+			tag, err := qtx.UpsertTag(r.Context(), database.UpsertTagParams{
+				OrganizationID: org.ID(),
+				Name:           name,
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not update tags")
+				return
+			}
+			_, err = qtx.AddTagToContact(r.Context(), database.AddTagToContactParams{
+				ContactID: contact.ID,
+				TagID:     tag.ID,
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not update tags")
+				return
+			}
+		}
+
+		// Only the difference goes on the timeline
+		for _, name := range names {
+			if slices.Contains(oldNames, name) {
+				continue
+			}
+			if err := recordTagActivity(r.Context(), qtx, contact, user.ID, activity.KindTagAdded, name); err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+		for _, name := range oldNames {
+			if slices.Contains(names, name) {
+				continue
+			}
+			if err := recordTagActivity(r.Context(), qtx, contact, user.ID, activity.KindTagRemoved, name); err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update tags")
+			return
+		}
+
+		writeContactTags(w, r, db, contact.ID)
+	}
+}
+
+func RemoveContactTagHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not remove tag")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		name := normalizeTagName(r.PathValue("tag"))
+		removed, err := qtx.RemoveTagFromContact(r.Context(), database.RemoveTagFromContactParams{
+			ContactID:      contact.ID,
+			OrganizationID: org.ID(),
+			Name:           name,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not remove tag")
+			return
+		}
+
+		if removed > 0 {
+			if err := recordTagActivity(r.Context(), qtx, contact, user.ID, activity.KindTagRemoved, name); err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not remove tag")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
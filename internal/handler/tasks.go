@@ -0,0 +1,451 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	TaskStatusOpen       = "open"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusDone       = "done"
+
+	taskPriorityNormal = "normal"
+)
+
+var (
+	taskStatuses   = []string{TaskStatusOpen, TaskStatusInProgress, TaskStatusDone}
+	taskPriorities = []string{"low", taskPriorityNormal, "high"}
+
+	// dueAtLayouts are tried in order; the second matches <input type="datetime-local">.
+	dueAtLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+)
+
+func NewTaskResponse(t database.Task) TaskResponse {
+	resp := TaskResponse{
+		ID:          t.ID,
+		ContactID:   t.ContactID,
+		Title:       t.Title,
+		Description: t.Description.String,
+		Priority:    t.Priority,
+		Status:      t.Status,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	if t.AssigneeID.Valid {
+		resp.AssigneeID = t.AssigneeID.UUID.String()
+	}
+	if t.DueAt.Valid {
+		resp.DueAt = &t.DueAt.Time
+	}
+	if t.CompletedAt.Valid {
+		resp.CompletedAt = &t.CompletedAt.Time
+	}
+	return resp
+}
+
+func parseDueAt(s string) (sql.NullTime, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	for _, layout := range dueAtLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return sql.NullTime{Time: t, Valid: true}, nil
+		}
+	}
+	return sql.NullTime{}, errors.New("due_at must be an RFC 3339 timestamp or a date (YYYY-MM-DD)")
+}
+
+// dueBefore turns the inbox ?due= filter into an upper bound on due_at.
+func dueBefore(filter string, now time.Time) (sql.NullTime, error) {
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+	switch filter {
+	case "":
+		return sql.NullTime{}, nil
+	case "overdue":
+		return sql.NullTime{Time: now, Valid: true}, nil
+	case "today":
+		return sql.NullTime{Time: endOfDay, Valid: true}, nil
+	case "week":
+		return sql.NullTime{Time: endOfDay.AddDate(0, 0, 7), Valid: true}, nil
+	}
+	return sql.NullTime{}, errors.New("due must be one of overdue, today or week")
+}
+
+func taskFromRequest(w http.ResponseWriter, r *http.Request, db *database.Queries, orgID uuid.UUID) (database.Task, bool) {
+	taskID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid task ID")
+		return database.Task{}, false
+	}
+
+	task, err := db.GetTaskByID(r.Context(), database.GetTaskByIDParams{
+		ID:             taskID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteJSONError(w, http.StatusNotFound, "Task not found")
+			return database.Task{}, false
+		}
+		WriteJSONError(w, http.StatusInternalServerError, "Could not fetch task")
+		return database.Task{}, false
+	}
+	return task, true
+}
+
+// LoadTaskInbox returns the tasks in the organization created by or assigned
+// to the user, filtered the same way as the /tasks/all endpoint.
+func LoadTaskInbox(r *http.Request, db *database.Queries, orgID, userID uuid.UUID) ([]TaskResponse, error) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status != "" && !slices.Contains(taskStatuses, status) {
+		return nil, errors.New("Invalid task status")
+	}
+
+	due, err := dueBefore(query.Get("due"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := db.GetTaskInbox(r.Context(), database.GetTaskInboxParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Status:         status,
+		IncludeDone:    parseBoolQuery(query.Get("include_done")),
+		DueBefore:      due,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]TaskResponse, len(tasks))
+	for i, t := range tasks {
+		resp[i] = NewTaskResponse(database.Task{
+			ID:             t.ID,
+			UserID:         t.UserID,
+			ContactID:      t.ContactID,
+			AssigneeID:     t.AssigneeID,
+			Title:          t.Title,
+			Description:    t.Description,
+			DueAt:          t.DueAt,
+			Priority:       t.Priority,
+			Status:         t.Status,
+			CompletedAt:    t.CompletedAt,
+			CreatedAt:      t.CreatedAt,
+			UpdatedAt:      t.UpdatedAt,
+			OrganizationID: t.OrganizationID,
+		})
+		resp[i].ContactName = t.ContactName
+	}
+	return resp, nil
+}
+
+func GetTaskInboxHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		tasks, err := LoadTaskInbox(r, db, org.ID(), user.ID)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"tasks": tasks})
+	}
+}
+
+func GetContactTasksHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		tasks, err := db.GetTasksByContact(r.Context(), database.GetTasksByContactParams{
+			ContactID:      contact.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch tasks")
+			return
+		}
+
+		resp := make([]TaskResponse, len(tasks))
+		for i, t := range tasks {
+			resp[i] = NewTaskResponse(t)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"tasks": resp})
+	}
+}
+
+func CreateTaskHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contact, ok := contactFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req CreateTaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		req.Title = strings.TrimSpace(req.Title)
+		if req.Title == "" {
+			WriteJSONError(w, http.StatusBadRequest, "Task title is required")
+			return
+		}
+
+		if req.Priority == "" {
+			req.Priority = taskPriorityNormal
+		}
+		if !slices.Contains(taskPriorities, req.Priority) {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid task priority")
+			return
+		}
+
+		if req.Status == "" {
+			req.Status = TaskStatusOpen
+		}
+		if !slices.Contains(taskStatuses, req.Status) {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid task status")
+			return
+		}
+
+		dueAt, err := parseDueAt(req.DueAt)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// New tasks are assigned to their creator unless the client says otherwise
+		assignee := uuid.NullUUID{UUID: user.ID, Valid: true}
+		if req.AssigneeID != nil {
+			if assignee, err = parseMemberID(r.Context(), db, org.ID(), *req.AssigneeID); err != nil {
+				writeMemberIDError(w, "assignee", err)
+				return
+			}
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create task")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		task, err := qtx.CreateTask(r.Context(), database.CreateTaskParams{
+			OrganizationID: org.ID(),
+			UserID:         user.ID,
+			ContactID:      contact.ID,
+			AssigneeID:     assignee,
+			Title:          req.Title,
+			Description:    ToNullString(strings.TrimSpace(req.Description)),
+			DueAt:          dueAt,
+			Priority:       req.Priority,
+			Status:         req.Status,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create task")
+			return
+		}
+
+		_, err = activity.Record(r.Context(), qtx, activity.Entry{
+			OrganizationID: org.ID(),
+			ContactID:      contact.ID,
+			ActorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+			Kind:           activity.KindTaskCreated,
+			Summary:        "Task created: " + task.Title,
+			Details:        map[string]any{"task_id": task.ID},
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create task")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(NewTaskResponse(task))
+	}
+}
+
+func UpdateTaskHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		existing, ok := taskFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		var req PatchTaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		title := existing.Title
+		if req.Title != nil {
+			title = strings.TrimSpace(*req.Title)
+			if title == "" {
+				WriteJSONError(w, http.StatusBadRequest, "Task title is required")
+				return
+			}
+		}
+
+		description := existing.Description
+		if req.Description != nil {
+			description = ToNullString(strings.TrimSpace(*req.Description))
+		}
+
+		priority := existing.Priority
+		if req.Priority != nil {
+			if !slices.Contains(taskPriorities, *req.Priority) {
+				WriteJSONError(w, http.StatusBadRequest, "Invalid task priority")
+				return
+			}
+			priority = *req.Priority
+		}
+
+		status := existing.Status
+		if req.Status != nil {
+			if !slices.Contains(taskStatuses, *req.Status) {
+				WriteJSONError(w, http.StatusBadRequest, "Invalid task status")
+				return
+			}
+			status = *req.Status
+		}
+
+		var err error
+		dueAt := existing.DueAt
+		if req.DueAt != nil {
+			if dueAt, err = parseDueAt(*req.DueAt); err != nil {
+				WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		assignee := existing.AssigneeID
+		if req.AssigneeID != nil {
+			if assignee, err = parseMemberID(r.Context(), db, org.ID(), *req.AssigneeID); err != nil {
+				writeMemberIDError(w, "assignee", err)
+				return
+			}
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update task")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		task, err := qtx.UpdateTask(r.Context(), database.UpdateTaskParams{
+			ID:             existing.ID,
+			OrganizationID: org.ID(),
+			AssigneeID:     assignee,
+			Title:          title,
+			Description:    description,
+			DueAt:          dueAt,
+			Priority:       priority,
+			Status:         status,
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update task")
+			return
+		}
+
+		if task.Status == TaskStatusDone && existing.Status != TaskStatusDone {
+			_, err := activity.Record(r.Context(), qtx, activity.Entry{
+				OrganizationID: org.ID(),
+				ContactID:      task.ContactID,
+				ActorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+				Kind:           activity.KindTaskCompleted,
+				Summary:        "Task completed: " + task.Title,
+				Details:        map[string]any{"task_id": task.ID},
+			})
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not update task")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewTaskResponse(task))
+	}
+}
+
+func DeleteTaskHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		task, ok := taskFromRequest(w, r, db, org.ID())
+		if !ok {
+			return
+		}
+
+		err := db.DeleteTask(r.Context(), database.DeleteTaskParams{
+			ID:             task.ID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete task")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
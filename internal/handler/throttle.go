@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/session"
+)
+
+// invalidLoginMessage is the same for unknown emails and wrong passwords, so
+// the login form can't be used to find out who is signed up.
+const invalidLoginMessage = "Invalid email or password"
+
+const (
+	// loginFailureWindow is how long failures are remembered. A failure after
+	// a quiet window starts the count again.
+	loginFailureWindow = time.Hour
+
+	// After this many failures an account is locked and its owner is emailed
+	// an unlock link.
+	accountLockoutFailures = 10
+	accountLockout         = time.Hour
+)
+
+// loginBackoff delays logins once more than freeAttempts have failed,
+// doubling the wait with every further failure up to maxDelay.
+type loginBackoff struct {
+	freeAttempts int32
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+}
+
+var (
+	// Offices and mobile networks share addresses, so IPs get more slack
+	ipBackoff      = loginBackoff{freeAttempts: 10, baseDelay: time.Second, maxDelay: 15 * time.Minute}
+	accountBackoff = loginBackoff{freeAttempts: 3, baseDelay: time.Second, maxDelay: 15 * time.Minute}
+)
+
+// Password reset requests are counted in the same table as login failures,
+// under keys of their own. An address gets one email a minute, doubling from
+// there; a client gets a few before it has to wait.
+var (
+	resetIPBackoff    = loginBackoff{freeAttempts: 5, baseDelay: time.Minute, maxDelay: time.Hour}
+	resetEmailBackoff = loginBackoff{freeAttempts: 0, baseDelay: time.Minute, maxDelay: time.Hour}
+)
+
+// dummyPasswordHash is checked when the email is unknown, so that case takes
+// as long as a wrong password.
+var dummyPasswordHash, _ = auth.HashPassword("not-a-real-password")
+
+func (b loginBackoff) delay(failures int32) time.Duration {
+	if failures <= b.freeAttempts {
+		return 0
+	}
+	exp := float64(failures - b.freeAttempts - 1)
+	d := time.Duration(float64(b.baseDelay) * math.Pow(2, exp))
+	if d <= 0 || d > b.maxDelay {
+		return b.maxDelay
+	}
+	return d
+}
+
+func ipThrottleKey(r *http.Request) string {
+	return "ip:" + session.ClientIP(r)
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + email
+}
+
+func resetIPThrottleKey(r *http.Request) string {
+	return "reset-ip:" + session.ClientIP(r)
+}
+
+func resetEmailThrottleKey(email string) string {
+	return "reset:" + email
+}
+
+// loginWait returns how long until any of the keys may try to log in again.
+func loginWait(ctx context.Context, db *database.Queries, keys ...string) (time.Duration, error) {
+	throttles, err := db.GetLoginThrottles(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for _, t := range throttles {
+		if t.LockedUntil.Valid {
+			wait = max(wait, time.Until(t.LockedUntil.Time))
+		}
+	}
+	return wait, nil
+}
+
+// recordLoginFailure counts a failure against the key and locks it for the
+// backoff delay. It returns the updated failure count.
+func recordLoginFailure(ctx context.Context, db *database.Queries, key string, backoff loginBackoff) (int32, error) {
+	t, err := db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:         key,
+		ResetBefore: time.Now().Add(-loginFailureWindow),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delay := backoff.delay(t.Failures)
+	if delay == 0 {
+		return t.Failures, nil
+	}
+	err = db.LockLogin(ctx, database.LockLoginParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(delay), Valid: true},
+	})
+	return t.Failures, err
+}
+
+// recordFailedLogin counts a failed password or second-factor check against
+// both the client IP and the account. Once the account reaches the lockout
+// threshold it is locked for longer and the owner gets an unlock link.
+func recordFailedLogin(ctx context.Context, db *database.Queries, mailer *email.Mailer, r *http.Request, accountEmail string, user *database.User) {
+	if _, err := recordLoginFailure(ctx, db, ipThrottleKey(r), ipBackoff); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+	}
+
+	key := accountThrottleKey(accountEmail)
+	failures, err := recordLoginFailure(ctx, db, key, accountBackoff)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return
+	}
+	if failures != accountLockoutFailures {
+		return
+	}
+
+	err = db.LockLogin(ctx, database.LockLoginParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(accountLockout), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Failed to lock account: %v", err)
+		return
+	}
+	if user == nil {
+		return
+	}
+
+	token, err := auth.GenerateVerificationToken()
+	if err != nil {
+		log.Printf("Failed to generate unlock token: %v", err)
+		return
+	}
+	err = db.SetLoginUnlockToken(ctx, database.SetLoginUnlockTokenParams{
+		Key:             key,
+		UnlockTokenHash: sql.NullString{String: auth.HashToken(token), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Failed to store unlock token: %v", err)
+		return
+	}
+
+	unlockLink := fmt.Sprintf("%s/unlock-account?token=%s", os.Getenv("APP_HOST"), token)
+	if err := mailer.Outbox(ctx, db).SendAccountLockedEmail(user.Email, user.FirstName, unlockLink, accountLockout); err != nil {
+		log.Println("failed to queue account locked email:", err)
+	}
+}
+
+// allowPasswordReset reports whether a reset email may be sent to the address
+// for this client, and counts the request against both when it may. The
+// answer doesn't depend on whether the address has an account.
+func allowPasswordReset(ctx context.Context, db *database.Queries, r *http.Request, accountEmail string) (bool, error) {
+	ipKey, emailKey := resetIPThrottleKey(r), resetEmailThrottleKey(accountEmail)
+	wait, err := loginWait(ctx, db, ipKey, emailKey)
+	if err != nil || wait > 0 {
+		return false, err
+	}
+
+	if _, err := recordLoginFailure(ctx, db, ipKey, resetIPBackoff); err != nil {
+		return false, err
+	}
+	if _, err := recordLoginFailure(ctx, db, emailKey, resetEmailBackoff); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	WriteJSONError(w, http.StatusTooManyRequests, "Too many login attempts. Please try again later.")
+}
+
+// UnlockAccount clears the lock on an account from the emailed token. It
+// reports whether the token matched a locked account.
+func UnlockAccount(ctx context.Context, db *database.Queries, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	unlocked, err := db.UnlockLogin(ctx, sql.NullString{String: auth.HashToken(token), Valid: true})
+	return unlocked > 0, err
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/MudassirDev/mini-hubspot/internal/activity"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+const defaultTrashRetentionDays = 30
+
+// TrashRetentionDays is how long trashed contacts are kept before the worker
+// purges them, set by TRASH_RETENTION_DAYS.
+func TrashRetentionDays() int {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return defaultTrashRetentionDays
+	}
+	return days
+}
+
+func GetTrashedContactsHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contacts, err := db.GetTrashedContacts(r.Context(), org.ID())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not fetch trash")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"contacts": NewContactResponseList(contacts)})
+	}
+}
+
+// RestoreContactHandler takes a contact out of the trash. Trashed contacts
+// don't count towards the contacts limit, so restoring one needs room in the
+// plan of whoever created it, reserved under lock like creating one.
+func RestoreContactHandler(conn *sql.DB, db *database.Queries, plans *entitlements.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contactID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid contact ID")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not restore contact")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := db.WithTx(tx)
+		trashed, err := qtx.GetTrashedContactByID(r.Context(), database.GetTrashedContactByIDParams{
+			ID:             contactID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				WriteJSONError(w, http.StatusNotFound, "Contact not found in trash")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not restore contact")
+			return
+		}
+
+		if trashed.CreatedBy.Valid {
+			creator, err := qtx.GetUserByID(r.Context(), trashed.CreatedBy.UUID)
+			if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not restore contact")
+				return
+			}
+
+			var limitErr *entitlements.LimitError
+			if err := reserveContacts(r.Context(), qtx, plans, &creator, 1); errors.As(err, &limitErr) {
+				http.Error(w, limitErr.Message(), http.StatusForbidden)
+				return
+			} else if err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Could not restore contact")
+				return
+			}
+		}
+
+		contact, err := qtx.RestoreContact(r.Context(), database.RestoreContactParams{
+			ID:             contactID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				WriteJSONError(w, http.StatusNotFound, "Contact not found in trash")
+				return
+			}
+			WriteJSONError(w, http.StatusInternalServerError, "Could not restore contact")
+			return
+		}
+
+		_, err = activity.Record(r.Context(), qtx, activity.Entry{
+			OrganizationID: org.ID(),
+			ContactID:      contact.ID,
+			ActorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+			Kind:           activity.KindContactRestored,
+			Summary:        "Restored from trash",
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not record activity")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not restore contact")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewContactResponse(contact))
+	}
+}
+
+// PurgeContactHandler permanently deletes a contact. Only trashed contacts can
+// be purged, so a contact always passes through the trash first.
+func PurgeContactHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, org, ok := currentMember(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		contactID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid contact ID")
+			return
+		}
+
+		purged, err := db.PurgeContact(r.Context(), database.PurgeContactParams{
+			ID:             contactID,
+			OrganizationID: org.ID(),
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not delete contact")
+			return
+		}
+		if purged == 0 {
+			WriteJSONError(w, http.StatusNotFound, "Contact not found in trash")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
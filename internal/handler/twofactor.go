@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+	"github.com/MudassirDev/mini-hubspot/internal/session"
+)
+
+const (
+	totpIssuer        = "MiniHubspot"
+	recoveryCodeCount = 10
+)
+
+// checkSecondFactor accepts a current TOTP code or an unused recovery code.
+// Either is spent on success, so the same code can't be replayed.
+func checkSecondFactor(ctx context.Context, db *database.Queries, user database.User, code string) (bool, error) {
+	if !user.TotpSecret.Valid {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now()); ok {
+		used, err := db.UseTOTPStep(ctx, database.UseTOTPStepParams{
+			ID:           user.ID,
+			TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+		return used > 0, err
+	}
+
+	code = auth.NormalizeRecoveryCode(code)
+	if len(code) != 11 {
+		return false, nil
+	}
+
+	codes, err := db.GetUnusedRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range codes {
+		if auth.VerifyPassword(code, c.CodeHash) == nil {
+			used, err := db.UseRecoveryCode(ctx, c.ID)
+			return used > 0, err
+		}
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes drops any old recovery codes and stores hashes of a
+// fresh set. The plain codes are only ever shown once.
+func replaceRecoveryCodes(ctx context.Context, db *database.Queries, user database.User) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.DeleteRecoveryCodes(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		hash, err := auth.HashPassword(code)
+		if err != nil {
+			return nil, err
+		}
+		err = db.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: hash,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// LoginTwoFactorHandler is the second login step. It needs the pending cookie
+// set by LoginHandler and starts the real session once the code checks out.
+// Wrong codes count towards the same throttles as wrong passwords.
+func LoginTwoFactorHandler(conn *sql.DB, db *database.Queries, sessions session.Config, mailer *email.Mailer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := session.PendingMFAUser(r, sessions)
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "Login expired, please sign in again")
+			return
+		}
+
+		var req TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		user, err := db.GetUserByID(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, http.StatusUnauthorized, "Login expired, please sign in again")
+			return
+		}
+
+		wait, err := loginWait(r.Context(), db, ipThrottleKey(r), accountThrottleKey(user.Email))
+		if err != nil {
+			log.Printf("DB error during two-factor login: %v", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if wait > 0 {
+			writeTooManyAttempts(w, wait)
+			return
+		}
+
+		ok, err := checkSecondFactor(r.Context(), db, user, req.Code)
+		if err != nil {
+			log.Printf("DB error during two-factor login: %v", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if !ok {
+			recordFailedLogin(r.Context(), db, mailer, r, user.Email, &user)
+			WriteJSONError(w, http.StatusUnauthorized, "Invalid code")
+			return
+		}
+
+		if err := db.ClearLoginThrottle(r.Context(), accountThrottleKey(user.Email)); err != nil {
+			log.Printf("Failed to clear login throttle: %v", err)
+		}
+
+		session.EndMFA(w)
+		if _, err := session.Start(r.Context(), db, w, r, sessions, user.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to start session")
+			return
+		}
+
+		resp := LoginResponse{
+			ID:    user.ID.String(),
+			Email: user.Email,
+			Plan:  user.Plan,
+			Role:  user.Role,
+		}
+		acceptInvitationOnLogin(r.Context(), conn, db, sessions.JwtSecret, req.InviteToken, user, &resp)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// SetupTwoFactorHandler starts enrollment with a new secret. It is not used
+// for logins until EnableTwoFactorHandler confirms a code from it.
+func SetupTwoFactorHandler(db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		if user.TotpEnabled {
+			WriteJSONError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+
+		secret, err := auth.GenerateTOTPSecret()
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to generate secret")
+			return
+		}
+
+		err = db.SetTOTPSecret(r.Context(), database.SetTOTPSecretParams{
+			ID:         user.ID,
+			TotpSecret: sql.NullString{String: secret, Valid: true},
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not start two-factor setup")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TwoFactorSetupResponse{
+			Secret:          secret,
+			ProvisioningURI: auth.TOTPProvisioningURI(secret, totpIssuer, user.Email),
+		})
+	}
+}
+
+// EnableTwoFactorHandler turns on 2FA once the user proves their app works,
+// and returns the recovery codes.
+func EnableTwoFactorHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		if user.TotpEnabled {
+			WriteJSONError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+
+		var req TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		step, valid := auth.ValidateTOTP(user.TotpSecret.String, req.Code, time.Now())
+		if !user.TotpSecret.Valid || !valid {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid code")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not enable two-factor authentication")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		_, err = qtx.UseTOTPStep(r.Context(), database.UseTOTPStepParams{
+			ID:           user.ID,
+			TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not enable two-factor authentication")
+			return
+		}
+
+		if err := qtx.EnableTOTP(r.Context(), user.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not enable two-factor authentication")
+			return
+		}
+
+		codes, err := replaceRecoveryCodes(r.Context(), qtx, *user)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create recovery codes")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not enable two-factor authentication")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"recovery_codes": codes})
+	}
+}
+
+// RegenerateRecoveryCodesHandler replaces all recovery codes, e.g. after the
+// user has used some of them.
+func RegenerateRecoveryCodesHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		if !user.TotpEnabled {
+			WriteJSONError(w, http.StatusBadRequest, "Two-factor authentication is not enabled")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create recovery codes")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		valid, err := checkSecondFactor(r.Context(), qtx, *user, req.Code)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create recovery codes")
+			return
+		}
+		if !valid {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid code")
+			return
+		}
+
+		codes, err := replaceRecoveryCodes(r.Context(), qtx, *user)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create recovery codes")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create recovery codes")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"recovery_codes": codes})
+	}
+}
+
+// DisableTwoFactorHandler turns 2FA off after checking a code. It is refused
+// while an admin requires 2FA for everyone.
+func DisableTwoFactorHandler(conn *sql.DB, db *database.Queries) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid JSON input")
+			return
+		}
+
+		if !user.TotpEnabled {
+			WriteJSONError(w, http.StatusBadRequest, "Two-factor authentication is not enabled")
+			return
+		}
+
+		settings, err := db.GetAppSettings(r.Context())
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not load settings")
+			return
+		}
+		if settings.RequireTwoFactor {
+			WriteJSONError(w, http.StatusForbidden, "Two-factor authentication is required by your administrator")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not disable two-factor authentication")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		valid, err := checkSecondFactor(r.Context(), qtx, *user, req.Code)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not disable two-factor authentication")
+			return
+		}
+		if !valid {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid code")
+			return
+		}
+
+		if err := qtx.DisableTOTP(r.Context(), user.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not disable two-factor authentication")
+			return
+		}
+		if err := qtx.DeleteRecoveryCodes(r.Context(), user.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not disable two-factor authentication")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not disable two-factor authentication")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
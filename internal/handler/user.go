@@ -14,6 +14,8 @@ import (
 	"github.com/MudassirDev/mini-hubspot/internal/auth"
 	"github.com/MudassirDev/mini-hubspot/internal/database"
 	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/organization"
+	"github.com/MudassirDev/mini-hubspot/internal/session"
 	"github.com/lib/pq"
 )
 
@@ -29,7 +31,11 @@ func WriteJSONError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
-func CreateUserHandler(db *database.Queries, EmailSender email.MailtrapEmailSender) http.HandlerFunc {
+// CreateUserHandler signs a user up. The verification email is queued in the
+// same transaction, so it goes out exactly when the account exists. Users
+// signing up from an invitation join that organization instead of getting a
+// personal one.
+func CreateUserHandler(conn *sql.DB, db *database.Queries, mailer *email.Mailer, inviteSecret string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateUserRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -43,6 +49,21 @@ func CreateUserHandler(db *database.Queries, EmailSender email.MailtrapEmailSend
 			WriteJSONError(w, http.StatusBadRequest, "Missing required fields: email, username or password")
 			return
 		}
+		if err := auth.CheckPassword(req.Password); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.InviteToken != "" {
+			inv, err := organization.FindInvitation(r.Context(), db, inviteSecret, req.InviteToken)
+			if err == nil && !organization.InvitedEmail(inv, req.Email) {
+				err = organization.ErrInvitationEmail
+			}
+			if err != nil {
+				writeInvitationError(w, err)
+				return
+			}
+		}
 
 		hashedPassword, err := auth.HashPassword(req.Password)
 		if err != nil {
@@ -58,7 +79,15 @@ func CreateUserHandler(db *database.Queries, EmailSender email.MailtrapEmailSend
 
 		tokenSentAt := time.Now()
 
-		user, err := db.CreateUser(r.Context(), database.CreateUserParams{
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create account")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		user, err := qtx.CreateUser(r.Context(), database.CreateUserParams{
 			Username:          req.Username,
 			Email:             req.Email,
 			FirstName:         req.FirstName,
@@ -94,12 +123,28 @@ func CreateUserHandler(db *database.Queries, EmailSender email.MailtrapEmailSend
 			return
 		}
 
+		if req.InviteToken != "" {
+			if _, err := organization.Accept(r.Context(), qtx, inviteSecret, req.InviteToken, user); err != nil {
+				writeInvitationError(w, err)
+				return
+			}
+		} else if _, err := organization.Create(r.Context(), qtx, organization.PersonalName(user), user.ID); err != nil {
+			log.Printf("Failed to create organization for %s: %v", user.Email, err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create account")
+			return
+		}
+
 		verifyLink := fmt.Sprintf("%s/verify-email?token=%s", os.Getenv("APP_HOST"), token)
 
-		err = EmailSender.SendVerificationEmail(req.Email, req.FirstName, verifyLink)
+		err = mailer.Outbox(r.Context(), qtx).SendVerificationEmail(req.Email, req.FirstName, verifyLink)
 		if err != nil {
-			log.Println("failed to send verification email:", err)
-			WriteJSONError(w, http.StatusInternalServerError, "Could not send verification email")
+			log.Println("failed to queue verification email:", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create account")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not create account")
 			return
 		}
 
@@ -116,7 +161,10 @@ func CreateUserHandler(db *database.Queries, EmailSender email.MailtrapEmailSend
 	}
 }
 
-func LoginHandler(db *database.Queries, jwtSecret string, expiresIn time.Duration) http.HandlerFunc {
+// LoginHandler checks the password. Failures are throttled per IP and per
+// account, and every failure gets the same message. An invite token from the
+// accept page joins the organization once the user is logged in.
+func LoginHandler(conn *sql.DB, db *database.Queries, sessions session.Config, mailer *email.Mailer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req LoginRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -130,47 +178,58 @@ func LoginHandler(db *database.Queries, jwtSecret string, expiresIn time.Duratio
 			return
 		}
 
-		user, err := db.GetUserByEmail(r.Context(), req.Email)
+		wait, err := loginWait(r.Context(), db, ipThrottleKey(r), accountThrottleKey(req.Email))
 		if err != nil {
-			// Check for sql.ErrNoRows specifically
-			if errors.Is(err, sql.ErrNoRows) {
-				WriteJSONError(w, http.StatusUnauthorized, "User not found")
-				return
-			}
+			log.Printf("DB error during login: %v", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if wait > 0 {
+			writeTooManyAttempts(w, wait)
+			return
+		}
 
+		user, err := db.GetUserByEmail(r.Context(), req.Email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			// Unexpected DB error
 			log.Printf("DB error during login: %v", err)
 			WriteJSONError(w, http.StatusInternalServerError, "Internal server error")
 			return
 		}
 
+		if err != nil {
+			auth.VerifyPassword(req.Password, dummyPasswordHash)
+			recordFailedLogin(r.Context(), db, mailer, r, req.Email, nil)
+			WriteJSONError(w, http.StatusUnauthorized, invalidLoginMessage)
+			return
+		}
+
 		if err := auth.VerifyPassword(req.Password, user.PasswordHash); err != nil {
-			WriteJSONError(w, http.StatusUnauthorized, "Invalid password")
+			recordFailedLogin(r.Context(), db, mailer, r, req.Email, &user)
+			WriteJSONError(w, http.StatusUnauthorized, invalidLoginMessage)
 			return
 		}
 
-		token, err := auth.MakeJWT(user.ID, expiresIn, jwtSecret)
-		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, "Failed to generate token")
+		if user.TotpEnabled {
+			if err := session.StartMFA(w, sessions, user.ID); err != nil {
+				WriteJSONError(w, http.StatusInternalServerError, "Failed to generate token")
+				return
+			}
+
+			// Nothing about the account is returned until the second step
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]bool{"mfa_required": true})
 			return
 		}
 
-		secure := IsProduction()
-		sameSite := http.SameSiteStrictMode
-		if secure {
-			sameSite = http.SameSiteNoneMode
+		if err := db.ClearLoginThrottle(r.Context(), accountThrottleKey(user.Email)); err != nil {
+			log.Printf("Failed to clear login throttle: %v", err)
 		}
 
-		// Set JWT as an HTTP-only cookie
-		http.SetCookie(w, &http.Cookie{
-			Name:     "auth_token",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   secure,
-			SameSite: sameSite,
-			MaxAge:   int((expiresIn).Seconds()),
-		})
+		if _, err := session.Start(r.Context(), db, w, r, sessions, user.ID); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to start session")
+			return
+		}
 
 		// Respond with basic user info (but no token)
 		resp := LoginResponse{
@@ -179,58 +238,21 @@ func LoginHandler(db *database.Queries, jwtSecret string, expiresIn time.Duratio
 			Plan:  user.Plan,
 			Role:  user.Role,
 		}
+		acceptInvitationOnLogin(r.Context(), conn, db, sessions.JwtSecret, req.InviteToken, user, &resp)
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
 	}
 }
 
-func VerifyEmailHandler(db *database.Queries) http.HandlerFunc {
+// LogoutHandler revokes the current session, not just the cookie, so the
+// refresh token can't be used again.
+func LogoutHandler(db *database.Queries) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		token := r.URL.Query().Get("token")
-		if token == "" {
-			WriteJSONError(w, http.StatusBadRequest, "Missing token")
-			return
-		}
-
-		user, err := db.GetUserByVerificationToken(r.Context(), sql.NullString{String: token, Valid: true})
-		if err != nil {
-			WriteJSONError(w, http.StatusBadRequest, "Invalid or expired token")
-			return
+		if err := session.End(r.Context(), db, w, r); err != nil {
+			log.Printf("Failed to revoke session on logout: %v", err)
 		}
 
-		if user.EmailVerified {
-			json.NewEncoder(w).Encode(map[string]string{"message": "Email already verified"})
-			return
-		}
-
-		// Optional: ensure token is not older than 30 days
-		if user.TokenSentAt.Valid && time.Since(user.TokenSentAt.Time) > 30*24*time.Hour {
-			WriteJSONError(w, http.StatusBadRequest, "Token expired")
-			return
-		}
-
-		err = db.VerifyUserEmail(r.Context(), user.ID)
-		if err != nil {
-			WriteJSONError(w, http.StatusInternalServerError, "Could not verify email")
-			return
-		}
-
-		json.NewEncoder(w).Encode(map[string]string{"message": "Email verified successfully"})
-	}
-}
-
-func LogoutHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		http.SetCookie(w, &http.Cookie{
-			Name:     "auth_token",
-			Value:    "",
-			Path:     "/",
-			MaxAge:   -1,
-			HttpOnly: true,
-			Secure:   false,
-		})
-
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 	}
 }
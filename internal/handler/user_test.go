@@ -0,0 +1,108 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/dbtest"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/handler"
+)
+
+func TestSignupSendsVerificationEmail(t *testing.T) {
+	conn := dbtest.Open(t)
+	db := database.New(conn)
+	ctx := context.Background()
+	t.Setenv("APP_HOST", "https://app.example.com")
+
+	mailer := &email.Mailer{
+		FromEmail:   "hello@example.com",
+		TemplateDir: filepath.Join("..", "..", email.DefaultTemplateDir),
+	}
+	signup := handler.CreateUserHandler(conn, db, mailer, "invite-secret")
+
+	body := `{"username":"ada","email":"Ada@Example.com","first_name":"Ada","last_name":"Lovelace","password":"correct horse"}`
+	rec := httptest.NewRecorder()
+	signup(rec, httptest.NewRequest(http.MethodPost, "/create-account", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("signup returned %d: %s", rec.Code, rec.Body)
+	}
+
+	// The email is queued with the account; the worker delivers it
+	dir := t.TempDir()
+	sent, failed, err := email.Deliver(ctx, db, &email.FileSender{Dir: dir}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || failed != 0 {
+		t.Fatalf("delivered %d, failed %d; want 1 sent", sent, failed)
+	}
+
+	user, err := db.GetUserByEmail(ctx, "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d .eml files, want 1", len(files))
+	}
+
+	header, text := readEML(t, files[0])
+	if got := header.Get("To"); got != "<ada@example.com>" {
+		t.Errorf("To = %q", got)
+	}
+	link := "https://app.example.com/verify-email?token=" + user.VerificationToken.String
+	if !strings.Contains(text, link) {
+		t.Errorf("email doesn't contain %s:\n%s", link, text)
+	}
+}
+
+// readEML parses a written email and returns its headers and decoded text
+// part.
+func readEML(t *testing.T, path string) (mail.Header, string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("no text part: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			body, err := io.ReadAll(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return msg.Header, string(body)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/email"
+	"github.com/MudassirDev/mini-hubspot/internal/middleware"
+)
+
+const (
+	// VerificationTokenExpiry matches the worker, which deletes accounts that
+	// stay unverified this long after their last link was sent.
+	VerificationTokenExpiry = 30 * 24 * time.Hour
+
+	// VerificationResendCooldown is how long a user has to wait between
+	// verification emails.
+	VerificationResendCooldown = 2 * time.Minute
+)
+
+// Outcomes of following a verification link, shown by the verify-email page
+const (
+	EmailVerified        = "verified"
+	EmailAlreadyVerified = "already_verified"
+	EmailTokenExpired    = "expired"
+	EmailTokenInvalid    = "invalid"
+)
+
+// VerifyEmail marks the account behind a verification token as verified and
+// returns one of the Email* outcomes.
+func VerifyEmail(ctx context.Context, db *database.Queries, token string) (string, error) {
+	if token == "" {
+		return EmailTokenInvalid, nil
+	}
+
+	user, err := db.GetUserByVerificationToken(ctx, sql.NullString{String: token, Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EmailTokenInvalid, nil
+		}
+		return "", err
+	}
+
+	if user.EmailVerified {
+		return EmailAlreadyVerified, nil
+	}
+
+	if user.TokenSentAt.Valid && time.Since(user.TokenSentAt.Time) > VerificationTokenExpiry {
+		return EmailTokenExpired, nil
+	}
+
+	if err := db.VerifyUserEmail(ctx, user.ID); err != nil {
+		return "", err
+	}
+	return EmailVerified, nil
+}
+
+// ResendVerificationHandler emails the logged-in user a new verification link,
+// which replaces the old one. Requests within VerificationResendCooldown of
+// the last email are refused.
+func ResendVerificationHandler(conn *sql.DB, db *database.Queries, mailer *email.Mailer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			WriteJSONError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		if user.EmailVerified {
+			WriteJSONError(w, http.StatusBadRequest, "Email is already verified")
+			return
+		}
+
+		token, err := auth.GenerateVerificationToken()
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+
+		tx, err := conn.BeginTx(r.Context(), nil)
+		if err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not resend verification email")
+			return
+		}
+		defer tx.Rollback()
+		qtx := db.WithTx(tx)
+
+		// The cooldown is checked in the update so two quick clicks can't both
+		// send an email
+		updated, err := qtx.ResendVerificationToken(r.Context(), database.ResendVerificationTokenParams{
+			VerificationToken: sql.NullString{String: token, Valid: true},
+			ID:                user.ID,
+			SentBefore:        time.Now().Add(-VerificationResendCooldown),
+		})
+		if err != nil {
+			log.Printf("Failed to store verification token: %v", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not resend verification email")
+			return
+		}
+		if updated == 0 {
+			wait := VerificationResendCooldown
+			if user.TokenSentAt.Valid {
+				wait = time.Until(user.TokenSentAt.Time.Add(VerificationResendCooldown))
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(max(wait, time.Second).Seconds()))))
+			WriteJSONError(w, http.StatusTooManyRequests, "A verification email was sent recently. Please wait a moment before asking for another.")
+			return
+		}
+
+		verifyLink := fmt.Sprintf("%s/verify-email?token=%s", os.Getenv("APP_HOST"), token)
+
+		err = mailer.Outbox(r.Context(), qtx).SendVerificationEmail(user.Email, user.FirstName, verifyLink)
+		if err != nil {
+			log.Println("failed to queue verification email:", err)
+			WriteJSONError(w, http.StatusInternalServerError, "Could not resend verification email")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, http.StatusInternalServerError, "Could not resend verification email")
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Verification email sent to " + user.Email})
+	}
+}
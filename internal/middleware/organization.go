@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/organization"
+)
+
+const OrganizationContextKey = contextKey("organization")
+
+// GetOrganizationFromContext retrieves the user's current organization from
+// context
+func GetOrganizationFromContext(ctx context.Context) (*organization.Membership, bool) {
+	m, ok := ctx.Value(OrganizationContextKey).(*organization.Membership)
+	return m, ok
+}
+
+// LoadOrganization attaches the organization the user is working in to
+// context. Requests without a user pass through untouched, so it has to run
+// again after RequireScope for routes open to API keys.
+func LoadOrganization(db *database.Queries) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, loaded := GetOrganizationFromContext(r.Context()); loaded {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m, err := organization.Current(r.Context(), db, user)
+			if err != nil {
+				log.Printf("Failed to load organization for user %s: %v", user.ID, err)
+				http.Error(w, "Could not load your organization", http.StatusInternalServerError)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), OrganizationContextKey, m)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireOrgRole ensures the user has one of the allowed roles in their
+// current organization
+func RequireOrgRole(allowedRoles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m, ok := GetOrganizationFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !slices.Contains(allowedRoles, m.Role) {
+				http.Error(w, "Forbidden: insufficient permissions in this organization", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
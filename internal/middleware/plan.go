@@ -1,15 +1,25 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/MudassirDev/mini-hubspot/internal/entitlements"
 )
 
-// RequirePlan ensures the user has the required subscription plan
-func RequirePlan(plan string) func(http.Handler) http.Handler {
+// UsageFunc counts how much of a plan limit the user has used. It takes a
+// context rather than the request so the worker can count the same way.
+type UsageFunc func(ctx context.Context, user *database.User) (int64, error)
+
+// RequireFeature ensures the user's plan includes the feature
+func RequireFeature(plans *entitlements.Registry, feature string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := GetUserFromContext(r.Context())
-			if !ok || user.Plan != plan {
+			if !ok || !plans.HasFeature(user.Plan, feature) {
 				http.Error(w, "Upgrade required to access this feature", http.StatusPaymentRequired) // 402
 				return
 			}
@@ -17,3 +27,31 @@ func RequirePlan(plan string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// CheckLimit ensures the user's plan has room for one more of the limited
+// resource
+func CheckLimit(plans *entitlements.Registry, limit string, usage UsageFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			used, err := usage(r.Context(), user)
+			if err != nil {
+				log.Printf("Failed to count %s for user %s: %v", limit, user.ID, err)
+				http.Error(w, "Could not check your plan limits", http.StatusInternalServerError)
+				return
+			}
+
+			var limitErr *entitlements.LimitError
+			if err := plans.CheckLimit(user.Plan, limit, used, 1); errors.As(err, &limitErr) {
+				http.Error(w, limitErr.Message(), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+)
+
+// RequireTwoFactor sends users without 2FA to set it up when an admin has
+// made it mandatory. Pages are redirected; API calls get a 403. API keys of
+// such users are refused too, even though RequireScope only puts their user
+// in context further down.
+func RequireTwoFactor(db *database.Queries) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			apiKey, isAPIKey := r.Context().Value(apiKeyContextKey).(*apiKeyAuth)
+			if !ok && isAPIKey {
+				user, ok = &apiKey.user, true
+			}
+			if !ok || user.TotpEnabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			settings, err := db.GetAppSettings(r.Context())
+			if err != nil {
+				http.Error(w, "Could not load settings", http.StatusInternalServerError)
+				return
+			}
+			if !settings.RequireTwoFactor {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if isAPIKey {
+				http.Error(w, "Set up two-factor authentication to use API keys", http.StatusForbidden)
+				return
+			}
+			if r.Method == http.MethodGet {
+				http.Redirect(w, r, "/account/2fa", http.StatusSeeOther)
+				return
+			}
+			http.Error(w, "Set up two-factor authentication to continue", http.StatusForbidden)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireVerifiedEmail keeps users who haven't confirmed their email address
+// away from a feature. Pages are redirected to the verify-email page; API
+// calls get a 403.
+func RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := GetUserFromContext(r.Context())
+		if !ok || user.EmailVerified {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, apiKey := bearerToken(r); r.Method == http.MethodGet && !apiKey {
+			http.Redirect(w, r, "/verify-email/pending", http.StatusSeeOther)
+			return
+		}
+		http.Error(w, "Verify your email address to use this feature", http.StatusForbidden)
+	})
+}
@@ -0,0 +1,149 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+// InvitationExpiry is how long an emailed invitation can be accepted.
+const InvitationExpiry = 7 * 24 * time.Hour
+
+var (
+	ErrAlreadyMember      = errors.New("user is already a member of the organization")
+	ErrInvitationInvalid  = errors.New("invitation is invalid")
+	ErrInvitationExpired  = errors.New("invitation has expired")
+	ErrInvitationRevoked  = errors.New("invitation has been revoked")
+	ErrInvitationAccepted = errors.New("invitation has already been accepted")
+	ErrInvitationEmail    = errors.New("invitation was sent to a different email address")
+)
+
+// Invite records an invitation for email to join the organization with role.
+// A pending invitation to the same address is revoked, so only the newest
+// link works.
+func Invite(ctx context.Context, db *database.Queries, orgID, inviter uuid.UUID, email, role string) (database.OrganizationInvitation, error) {
+	if !ValidRole(role) {
+		return database.OrganizationInvitation{}, ErrInvalidRole
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	user, err := db.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.OrganizationInvitation{}, err
+	}
+	if err == nil {
+		member, err := IsMember(ctx, db, orgID, user.ID)
+		if err != nil {
+			return database.OrganizationInvitation{}, err
+		}
+		if member {
+			return database.OrganizationInvitation{}, ErrAlreadyMember
+		}
+	}
+
+	err = db.RevokePendingInvitationsForEmail(ctx, database.RevokePendingInvitationsForEmailParams{
+		OrganizationID: orgID,
+		Email:          email,
+	})
+	if err != nil {
+		return database.OrganizationInvitation{}, err
+	}
+
+	return db.CreateOrganizationInvitation(ctx, database.CreateOrganizationInvitationParams{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      uuid.NullUUID{UUID: inviter, Valid: true},
+		ExpiresAt:      time.Now().Add(InvitationExpiry),
+	})
+}
+
+// InvitationToken signs the token for an invitation's emailed link.
+func InvitationToken(inv database.OrganizationInvitation, secret string) (string, error) {
+	return auth.MakeInvitationToken(inv.ID, inv.ExpiresAt, secret)
+}
+
+// FindInvitation checks a signed invitation token and returns the invitation
+// if it can still be accepted.
+func FindInvitation(ctx context.Context, db *database.Queries, secret, token string) (database.GetOrganizationInvitationRow, error) {
+	id, err := auth.VerifyInvitationToken(token, secret)
+	if errors.Is(err, auth.ErrExpiredToken) {
+		return database.GetOrganizationInvitationRow{}, ErrInvitationExpired
+	}
+	if err != nil {
+		return database.GetOrganizationInvitationRow{}, ErrInvitationInvalid
+	}
+
+	inv, err := db.GetOrganizationInvitation(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.GetOrganizationInvitationRow{}, ErrInvitationInvalid
+	}
+	if err != nil {
+		return database.GetOrganizationInvitationRow{}, err
+	}
+
+	switch {
+	case inv.AcceptedAt.Valid:
+		return inv, ErrInvitationAccepted
+	case inv.RevokedAt.Valid:
+		return inv, ErrInvitationRevoked
+	case time.Now().After(inv.ExpiresAt):
+		return inv, ErrInvitationExpired
+	}
+	return inv, nil
+}
+
+// InvitedEmail reports whether the invitation was sent to email.
+func InvitedEmail(inv database.GetOrganizationInvitationRow, email string) bool {
+	return strings.EqualFold(inv.Email, strings.TrimSpace(email))
+}
+
+// Accept adds the user to the organization they were invited to and switches
+// them to it. Only the invited email address can accept. Someone who is
+// already a member keeps their role. Pass queries bound to a transaction.
+func Accept(ctx context.Context, db *database.Queries, secret, token string, user database.User) (*Membership, error) {
+	inv, err := FindInvitation(ctx, db, secret, token)
+	if err != nil {
+		return nil, err
+	}
+	if !InvitedEmail(inv, user.Email) {
+		return nil, ErrInvitationEmail
+	}
+
+	accepted, err := db.AcceptOrganizationInvitation(ctx, database.AcceptOrganizationInvitationParams{
+		ID:         inv.ID,
+		AcceptedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if accepted == 0 {
+		// Revoked or accepted since it was looked up
+		return nil, ErrInvitationInvalid
+	}
+
+	err = db.AddOrganizationMember(ctx, database.AddOrganizationMemberParams{
+		OrganizationID: inv.OrganizationID,
+		UserID:         user.ID,
+		Role:           inv.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.SetCurrentOrganization(ctx, database.SetCurrentOrganizationParams{
+		ID:                    user.ID,
+		CurrentOrganizationID: uuid.NullUUID{UUID: inv.OrganizationID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return Get(ctx, db, inv.OrganizationID, user.ID)
+}
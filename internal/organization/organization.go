@@ -0,0 +1,244 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLastAdmin   = errors.New("organization needs at least one admin")
+	ErrNotAMember  = errors.New("user is not a member of the organization")
+	ErrInvalidRole = errors.New("invalid organization role")
+)
+
+// Membership is an organization as seen by one of its members. Members have
+// one of the account roles, auth.RoleUser or auth.RoleAdmin, within each
+// organization; admins manage the organization and its members.
+type Membership struct {
+	Organization database.Organization
+	Role         string
+}
+
+// ID returns the organization's ID.
+func (m *Membership) ID() uuid.UUID {
+	return m.Organization.ID
+}
+
+// IsAdmin reports whether the member can manage the organization.
+func (m *Membership) IsAdmin() bool {
+	return m.Role == auth.RoleAdmin
+}
+
+// ValidRole reports whether role can be given to a member.
+func ValidRole(role string) bool {
+	return role == auth.RoleUser || role == auth.RoleAdmin
+}
+
+// PersonalName is the name of the organization created for a new user.
+func PersonalName(user database.User) string {
+	return strings.TrimSpace(user.FirstName) + "'s workspace"
+}
+
+// Create sets up an organization with owner as its admin and switches the
+// owner to it. Pass queries bound to a transaction so a failure doesn't leave
+// an organization without members.
+func Create(ctx context.Context, db *database.Queries, name string, owner uuid.UUID) (database.Organization, error) {
+	org, err := db.CreateOrganization(ctx, name)
+	if err != nil {
+		return database.Organization{}, err
+	}
+
+	err = db.AddOrganizationMember(ctx, database.AddOrganizationMemberParams{
+		OrganizationID: org.ID,
+		UserID:         owner,
+		Role:           auth.RoleAdmin,
+	})
+	if err != nil {
+		return database.Organization{}, err
+	}
+
+	err = db.SetCurrentOrganization(ctx, database.SetCurrentOrganizationParams{
+		ID:                    owner,
+		CurrentOrganizationID: uuid.NullUUID{UUID: org.ID, Valid: true},
+	})
+	return org, err
+}
+
+// Get returns the user's membership of an organization, or ErrNotAMember.
+func Get(ctx context.Context, db *database.Queries, orgID, userID uuid.UUID) (*Membership, error) {
+	row, err := db.GetOrganizationMembership(ctx, database.GetOrganizationMembershipParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotAMember
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Membership{
+		Organization: database.Organization{
+			ID:        row.ID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		},
+		Role: row.Role,
+	}, nil
+}
+
+// Current returns the organization the user is working in. If they have left
+// it or never picked one, they are moved to the first organization they
+// belong to, and a user without any gets a new personal organization.
+func Current(ctx context.Context, db *database.Queries, user *database.User) (*Membership, error) {
+	if user.CurrentOrganizationID.Valid {
+		m, err := Get(ctx, db, user.CurrentOrganizationID.UUID, user.ID)
+		if !errors.Is(err, ErrNotAMember) {
+			return m, err
+		}
+	}
+
+	orgs, err := db.GetOrganizationsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var m *Membership
+	if len(orgs) > 0 {
+		m = &Membership{
+			Organization: database.Organization{
+				ID:        orgs[0].ID,
+				Name:      orgs[0].Name,
+				CreatedAt: orgs[0].CreatedAt,
+				UpdatedAt: orgs[0].UpdatedAt,
+			},
+			Role: orgs[0].Role,
+		}
+		err = db.SetCurrentOrganization(ctx, database.SetCurrentOrganizationParams{
+			ID:                    user.ID,
+			CurrentOrganizationID: uuid.NullUUID{UUID: m.ID(), Valid: true},
+		})
+	} else {
+		var org database.Organization
+		org, err = Create(ctx, db, PersonalName(*user), user.ID)
+		m = &Membership{Organization: org, Role: auth.RoleAdmin}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user.CurrentOrganizationID = uuid.NullUUID{UUID: m.ID(), Valid: true}
+	return m, nil
+}
+
+// SetRole changes a member's role. The last admin can't be demoted. Pass
+// queries bound to a transaction; the organization row is locked so two
+// admins can't demote each other at the same time.
+func SetRole(ctx context.Context, db *database.Queries, orgID, userID uuid.UUID, role string) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	if _, err := db.LockOrganization(ctx, orgID); err != nil {
+		return err
+	}
+
+	member, err := db.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotAMember
+	}
+	if err != nil {
+		return err
+	}
+
+	if member.Role == auth.RoleAdmin && role != auth.RoleAdmin {
+		if err := requireAnotherAdmin(ctx, db, orgID); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.UpdateOrganizationMemberRole(ctx, database.UpdateOrganizationMemberRoleParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+	})
+	return err
+}
+
+// RemoveMember takes a user out of an organization. Their contacts and deals
+// stay with the organization without an owner. The last admin can't be removed. Pass
+// queries bound to a transaction.
+func RemoveMember(ctx context.Context, db *database.Queries, orgID, userID uuid.UUID) error {
+	if _, err := db.LockOrganization(ctx, orgID); err != nil {
+		return err
+	}
+
+	member, err := db.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotAMember
+	}
+	if err != nil {
+		return err
+	}
+
+	if member.Role == auth.RoleAdmin {
+		if err := requireAnotherAdmin(ctx, db, orgID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.RemoveOrganizationMember(ctx, database.RemoveOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	}); err != nil {
+		return err
+	}
+
+	owner := uuid.NullUUID{UUID: userID, Valid: true}
+	if err := db.ReleaseContactsOwnedBy(ctx, database.ReleaseContactsOwnedByParams{
+		OrganizationID: orgID,
+		OwnerID:        owner,
+	}); err != nil {
+		return err
+	}
+	return db.ReleaseDealsOwnedBy(ctx, database.ReleaseDealsOwnedByParams{
+		OrganizationID: orgID,
+		OwnerID:        owner,
+	})
+}
+
+func requireAnotherAdmin(ctx context.Context, db *database.Queries, orgID uuid.UUID) error {
+	admins, err := db.CountOrganizationAdmins(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// IsMember reports whether the user belongs to the organization, for checking
+// contact owners and task assignees.
+func IsMember(ctx context.Context, db *database.Queries, orgID, userID uuid.UUID) (bool, error) {
+	_, err := db.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
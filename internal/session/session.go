@@ -0,0 +1,238 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/MudassirDev/mini-hubspot/internal/auth"
+	"github.com/MudassirDev/mini-hubspot/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	AccessCookie  = "auth_token"
+	RefreshCookie = "refresh_token"
+	MFACookie     = "mfa_token"
+
+	// mfaExpiry is how long a user has to enter their second factor after
+	// their password
+	mfaExpiry = 5 * time.Minute
+
+	// rotationGrace lets requests that raced a refresh keep using the token
+	// that was just rotated out instead of being treated as token theft.
+	rotationGrace = 30 * time.Second
+)
+
+var (
+	ErrNoRefreshToken = errors.New("no refresh token")
+	ErrSessionEnded   = errors.New("session revoked or expired")
+	ErrTokenReused    = errors.New("refresh token reused")
+)
+
+// Config controls how long tokens live and how their cookies are set.
+type Config struct {
+	JwtSecret     string
+	AccessExpiry  time.Duration
+	RefreshExpiry time.Duration
+	Secure        bool
+}
+
+// Start opens a new session for the user and sets both token cookies.
+func Start(ctx context.Context, db *database.Queries, w http.ResponseWriter, r *http.Request, cfg Config, userID uuid.UUID) (database.Session, error) {
+	refreshToken, err := auth.GenerateVerificationToken()
+	if err != nil {
+		return database.Session{}, err
+	}
+
+	s, err := db.CreateSession(ctx, database.CreateSessionParams{
+		UserID:           userID,
+		RefreshTokenHash: auth.HashToken(refreshToken),
+		UserAgent:        r.UserAgent(),
+		IpAddress:        ClientIP(r),
+		ExpiresAt:        time.Now().Add(cfg.RefreshExpiry),
+	})
+	if err != nil {
+		return database.Session{}, err
+	}
+
+	if err := setAccessCookie(w, cfg, s); err != nil {
+		return database.Session{}, err
+	}
+	setCookie(w, cfg, RefreshCookie, refreshToken, cfg.RefreshExpiry)
+	return s, nil
+}
+
+// Validate checks that the session behind an access token is still live.
+func Validate(ctx context.Context, db *database.Queries, claims auth.Claims) (database.Session, error) {
+	s, err := db.GetSessionByID(ctx, claims.SessionID)
+	if err != nil {
+		return database.Session{}, err
+	}
+	if s.UserID != claims.UserID || !active(s) {
+		return database.Session{}, ErrSessionEnded
+	}
+	return s, nil
+}
+
+// Refresh trades the refresh cookie for a new access token and a new refresh
+// token. Presenting a refresh token that has already been rotated out means
+// it was copied, so the whole session is revoked.
+func Refresh(ctx context.Context, db *database.Queries, w http.ResponseWriter, r *http.Request, cfg Config) (database.Session, error) {
+	cookie, err := r.Cookie(RefreshCookie)
+	if err != nil || cookie.Value == "" {
+		return database.Session{}, ErrNoRefreshToken
+	}
+	tokenHash := auth.HashToken(cookie.Value)
+
+	s, err := db.GetSessionByRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Session{}, ErrSessionEnded
+		}
+		return database.Session{}, err
+	}
+	if !active(s) {
+		return database.Session{}, ErrSessionEnded
+	}
+
+	if s.RefreshTokenHash != tokenHash {
+		if s.RotatedAt.Valid && time.Since(s.RotatedAt.Time) < rotationGrace {
+			return s, setAccessCookie(w, cfg, s)
+		}
+		if _, err := db.RevokeSession(ctx, database.RevokeSessionParams{ID: s.ID, UserID: s.UserID}); err != nil {
+			return database.Session{}, err
+		}
+		return database.Session{}, ErrTokenReused
+	}
+
+	refreshToken, err := auth.GenerateVerificationToken()
+	if err != nil {
+		return database.Session{}, err
+	}
+	rotated, err := db.RotateSessionToken(ctx, database.RotateSessionTokenParams{
+		NewTokenHash: auth.HashToken(refreshToken),
+		ExpiresAt:    time.Now().Add(cfg.RefreshExpiry),
+		ID:           s.ID,
+		OldTokenHash: tokenHash,
+	})
+	if err != nil {
+		return database.Session{}, err
+	}
+	if rotated == 0 {
+		// Another request rotated it first and already set the new cookie
+		return s, setAccessCookie(w, cfg, s)
+	}
+
+	if err := setAccessCookie(w, cfg, s); err != nil {
+		return database.Session{}, err
+	}
+	setCookie(w, cfg, RefreshCookie, refreshToken, cfg.RefreshExpiry)
+	return s, nil
+}
+
+// End revokes the session the request belongs to, if any, and clears the
+// cookies.
+func End(ctx context.Context, db *database.Queries, w http.ResponseWriter, r *http.Request) error {
+	defer ClearCookies(w)
+
+	cookie, err := r.Cookie(RefreshCookie)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+
+	s, err := db.GetSessionByRefreshToken(ctx, auth.HashToken(cookie.Value))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	_, err = db.RevokeSession(ctx, database.RevokeSessionParams{ID: s.ID, UserID: s.UserID})
+	return err
+}
+
+// StartMFA marks a login as waiting for its second factor. No session exists
+// until the second factor is checked and Start is called.
+func StartMFA(w http.ResponseWriter, cfg Config, userID uuid.UUID) error {
+	token, err := auth.MakeMFAToken(userID, mfaExpiry, cfg.JwtSecret)
+	if err != nil {
+		return err
+	}
+	setCookie(w, cfg, MFACookie, token, mfaExpiry)
+	return nil
+}
+
+// PendingMFAUser returns the user who passed the password step but has not
+// entered their second factor yet.
+func PendingMFAUser(r *http.Request, cfg Config) (uuid.UUID, error) {
+	cookie, err := r.Cookie(MFACookie)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.VerifyMFAToken(cookie.Value, cfg.JwtSecret)
+}
+
+// EndMFA drops the pending second-factor cookie once it has been used.
+func EndMFA(w http.ResponseWriter) {
+	clearCookie(w, MFACookie)
+}
+
+// ClearCookies removes all token cookies from the browser.
+func ClearCookies(w http.ResponseWriter) {
+	for _, name := range []string{AccessCookie, RefreshCookie, MFACookie} {
+		clearCookie(w, name)
+	}
+}
+
+// ClientIP is the address the request came from, without the port.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func active(s database.Session) bool {
+	return !s.RevokedAt.Valid && time.Now().Before(s.ExpiresAt)
+}
+
+func setAccessCookie(w http.ResponseWriter, cfg Config, s database.Session) error {
+	token, err := auth.MakeJWT(s.UserID, s.ID, cfg.AccessExpiry, cfg.JwtSecret)
+	if err != nil {
+		return err
+	}
+	setCookie(w, cfg, AccessCookie, token, cfg.AccessExpiry)
+	return nil
+}
+
+func setCookie(w http.ResponseWriter, cfg Config, name, value string, maxAge time.Duration) {
+	sameSite := http.SameSiteStrictMode
+	if cfg.Secure {
+		sameSite = http.SameSiteNoneMode
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   cfg.Secure,
+		SameSite: sameSite,
+		MaxAge:   int(maxAge.Seconds()),
+	})
+}
+
+func clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}